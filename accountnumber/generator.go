@@ -0,0 +1,7 @@
+package accountnumber
+
+// Generator makes account numbers for new accounts. Numbers are random, so a new one can match an existing
+// account and the caller should try again with another
+type Generator interface {
+	Generate() string
+}
@@ -0,0 +1,62 @@
+package accountnumber
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const (
+	// NUBANLength is the number of digits of an account number, the serial number followed by a check digit
+	NUBANLength  = 10
+	serialLength = NUBANLength - 1
+	serialRange  = 1_000_000_000
+)
+
+// the CBN weights for the 3 digit bank code followed by the 9 digit serial number
+var nubanWeights = [12]int{3, 7, 3, 3, 7, 3, 3, 7, 3, 3, 7, 3}
+
+// NUBANGenerator makes Nigeria Uniform Bank Account Numbers, a random serial number and a check digit
+// that is computed together with the bank's code
+type NUBANGenerator struct {
+	bankCode string
+}
+
+// NewNUBANGenerator creates a generator for the bank with the 3 digit CBN bank code
+func NewNUBANGenerator(bankCode string) (Generator, error) {
+	if len(bankCode) != 3 || !isDigits(bankCode) {
+		return nil, fmt.Errorf("invalid bank code %q: must be 3 digits", bankCode)
+	}
+	return &NUBANGenerator{bankCode: bankCode}, nil
+}
+
+// Generate returns a random account number with a valid check digit
+func (generator *NUBANGenerator) Generate() string {
+	serial := fmt.Sprintf("%0*d", serialLength, rand.Int63n(serialRange))
+	return serial + string(CheckDigit(generator.bankCode, serial))
+}
+
+// CheckDigit computes the NUBAN check digit of a 9 digit serial number at the bank with the 3 digit code
+func CheckDigit(bankCode string, serial string) byte {
+	sum := 0
+	for i, digit := range bankCode + serial {
+		sum += int(digit-'0') * nubanWeights[i]
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ValidNUBAN reports whether the number is 10 digits long and ends with the check digit for the bank
+func ValidNUBAN(bankCode string, number string) bool {
+	if len(bankCode) != 3 || !isDigits(bankCode) || len(number) != NUBANLength || !isDigits(number) {
+		return false
+	}
+	return CheckDigit(bankCode, number[:serialLength]) == number[serialLength]
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
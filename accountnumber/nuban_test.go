@@ -0,0 +1,37 @@
+package accountnumber
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDigit(t *testing.T) {
+	// the worked example of the CBN NUBAN specification
+	assert.Equal(t, byte('9'), CheckDigit("011", "000001457"))
+
+	assert.True(t, ValidNUBAN("011", "0000014579"))
+	assert.False(t, ValidNUBAN("011", "0000014578"))
+	assert.False(t, ValidNUBAN("058", "0000014579"))
+	assert.False(t, ValidNUBAN("011", "000014579"))
+	assert.False(t, ValidNUBAN("011", "00000145a9"))
+}
+
+func TestNUBANGenerator(t *testing.T) {
+	generator, err := NewNUBANGenerator("058")
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		number := generator.Generate()
+		assert.Len(t, number, NUBANLength)
+		assert.True(t, ValidNUBAN("058", number))
+	}
+}
+
+func TestNewNUBANGenerator(t *testing.T) {
+	_, err := NewNUBANGenerator("58")
+	assert.Error(t, err)
+
+	_, err = NewNUBANGenerator("05a")
+	assert.Error(t, err)
+}
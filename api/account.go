@@ -6,15 +6,42 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
 	"github.com/kelvinator07/golang-bank-microservices/token"
-	"github.com/kelvinator07/golang-bank-microservices/util"
 )
 
-const (
-	active   string = "active"
-	inactive string = "inactive"
-)
+// accountResponse renders the stored account's amounts as decimals, available_balance is what the account
+// can spend right now and is only filled in where the account is looked up on its own
+type accountResponse struct {
+	db.Account
+	Balance          string `json:"balance"`
+	OverdraftLimit   string `json:"overdraft_limit"`
+	AvailableBalance string `json:"available_balance,omitempty"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		Account:        account,
+		Balance:        formatAmount(account.Balance, account.CurrencyCode),
+		OverdraftLimit: formatAmount(account.OverdraftLimit, account.CurrencyCode),
+	}
+}
+
+func (server *Server) availableAccountResponse(ctx *gin.Context, account db.Account) (accountResponse, error) {
+	held, err := server.store.GetAccountHeldAmount(ctx, account.ID)
+	if err != nil {
+		return accountResponse{}, err
+	}
+
+	rsp := newAccountResponse(account)
+	rsp.AvailableBalance = formatAmount(db.AvailableBalance(account, held), account.CurrencyCode)
+	return rsp, nil
+}
+
+// maxAccountNumberAttempts is how many account numbers are tried before creating an account fails,
+// with a billion numbers per bank code a collision is rare and several in a row are rarer still
+const maxAccountNumberAttempts = 5
 
 type createAccountRequest struct {
 	CurrencyCode string `json:"currency_code" binding:"required,currencyCode"`
@@ -30,15 +57,28 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	// Get user ID from request header
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := db.CreateAccountParams{
-		UserID:        authPayload.UserID,
-		AccountNumber: util.RandomAccountNumber(),
-		Status:        inactive,
-		Balance:       0,
-		CurrencyCode:  req.CurrencyCode,
+		UserID:       pgtype.Int8{Int64: authPayload.UserID, Valid: true},
+		Status:       db.AccountStatusInactive,
+		Balance:      0,
+		CurrencyCode: req.CurrencyCode,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	// account numbers are random, one that is already taken is replaced with another
+	var account db.Account
+	var err error
+	for attempt := 1; attempt <= maxAccountNumberAttempts; attempt++ {
+		arg.AccountNumber = server.accountNumbers.Generate()
+		account, err = server.store.CreateAccount(ctx, arg)
+		if db.ErrorConstraint(err) != db.AccountNumberIndex {
+			break
+		}
+	}
 	if err != nil {
+		if db.ErrorConstraint(err) == db.AccountNumberIndex {
+			err = errors.New("no unused account number was found, please try again")
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
 		errCode := db.ErrorCode(err)
 		if errCode == db.UniqueViolation || errCode == db.ForeignKeyViolation {
 			ctx.JSON(http.StatusBadRequest, errorResponse(err))
@@ -48,7 +88,10 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	// a new account has nothing on hold yet
+	rsp := newAccountResponse(account)
+	rsp.AvailableBalance = formatAmount(db.AvailableBalance(account, 0), account.CurrencyCode)
+	ctx.JSON(http.StatusOK, rsp)
 }
 
 type getAccountRequest struct {
@@ -62,26 +105,66 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	account, valid := server.ownedAccount(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	rsp, err := server.availableAccountResponse(ctx, account)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getAccountByNumberRequest struct {
+	Number string `uri:"number" binding:"required,len=10,number"`
+}
+
+// accountLookupResponse is what anyone can see of an account, enough to check who they are paying
+type accountLookupResponse struct {
+	ID            int64  `json:"id"`
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+	CurrencyCode  string `json:"currency_code"`
+	Status        string `json:"status"`
+}
+
+// getAccountByNumber finds a customer account by its account number, so a user can get the id to transfer to
+// and confirm whose account it is first
+func (server *Server) getAccountByNumber(ctx *gin.Context) {
+	var req getAccountByNumberRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccountByNumber(ctx, req.Number)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
-			err = fmt.Errorf("account with id %v doesnt exist", req.ID)
+			err = fmt.Errorf("account with number %v doesnt exist", req.Number)
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
-
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.UserID != authPayload.UserID {
-		err := errors.New("account doesnt belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	owner, err := server.store.GetUser(ctx, account.UserID.Int64)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, validResponse(accountLookupResponse{
+		ID:            account.ID,
+		AccountNumber: account.AccountNumber,
+		AccountName:   owner.AccountName,
+		CurrencyCode:  account.CurrencyCode,
+		Status:        account.Status,
+	}))
 }
 
 type getAllAccountsRequest struct {
@@ -98,7 +181,7 @@ func (server *Server) getAllAccounts(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	arg := db.ListAccountsParams{
-		UserID: authPayload.UserID,
+		UserID: pgtype.Int8{Int64: authPayload.UserID, Valid: true},
 		Limit:  req.PageSize,
 		Offset: (req.PageID - 1) * req.PageSize,
 	}
@@ -109,5 +192,156 @@ func (server *Server) getAllAccounts(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	// the held amounts of the whole page are fetched at once, an account missing from them has nothing on hold
+	ids := make([]int64, 0, len(accounts))
+	for _, account := range accounts {
+		ids = append(ids, account.ID)
+	}
+
+	heldAmounts, err := server.store.ListAccountHeldAmounts(ctx, ids)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	held := make(map[int64]int64, len(heldAmounts))
+	for _, row := range heldAmounts {
+		held[row.AccountID] = row.HeldAmount
+	}
+
+	rsp := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		accountRsp := newAccountResponse(account)
+		accountRsp.AvailableBalance = formatAmount(db.AvailableBalance(account, held[account.ID]), account.CurrencyCode)
+		rsp = append(rsp, accountRsp)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateAccountStatusRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+func (server *Server) activateAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusInactive, db.AccountStatusActive, server.ownedAccount)
+}
+
+func (server *Server) closeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, "", db.AccountStatusClosed, server.ownedAccount)
+}
+
+// updateAccountStatus moves the account in the uri to toStatus, getAccount decides who may change it.
+// An empty fromStatus accepts any status the account state machine allows.
+func (server *Server) updateAccountStatus(ctx *gin.Context, fromStatus string, toStatus string, getAccount func(*gin.Context, int64) (db.Account, bool)) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the reason is optional, so an empty body is fine
+	var req updateAccountStatusRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	account, valid := getAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	if fromStatus != "" && account.Status != fromStatus {
+		err := fmt.Errorf("account [%d] is %s, expected %s", account.ID, account.Status, fromStatus)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    toStatus,
+		ChangedBy: authPayload.UserID,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidStatusTransition) || errors.Is(err, db.ErrAccountBalanceNotZero) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(updateAccountStatusResponse{
+		Account:       newAccountResponse(result.Account),
+		StatusHistory: result.StatusHistory,
+	}))
+}
+
+type updateAccountStatusResponse struct {
+	Account       accountResponse         `json:"account"`
+	StatusHistory db.AccountStatusHistory `json:"status_history"`
+}
+
+type listAccountStatusHistoryRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listAccountStatusHistory(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listAccountStatusHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	history, err := server.store.ListAccountStatusHistory(ctx, db.ListAccountStatusHistoryParams{
+		AccountID: account.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(history))
+}
+
+// ownedAccount fetches an account and checks it belongs to the authenticated user
+func (server *Server) ownedAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("account with id %v doesnt exist", accountID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !account.OwnedBy(authPayload.UserID) {
+		err := errors.New("account doesnt belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
 }
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+// GET /api/v1/accounts/:id/balance?at=2024-01-01T00:00:00Z
+type accountBalanceRequest struct {
+	At time.Time `form:"at" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type accountBalanceResponse struct {
+	AccountID    int64     `json:"account_id"`
+	CurrencyCode string    `json:"currency_code"`
+	Balance      string    `json:"balance"`
+	At           time.Time `json:"at"`
+}
+
+// getAccountBalance returns the balance of an account as it was at a point in time,
+// every entry created before then is counted
+func (server *Server) getAccountBalance(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req accountBalanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.At.After(time.Now()) {
+		err := errors.New("at must not be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	balance, err := server.store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		AccountID: account.ID,
+		At:        req.At,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(accountBalanceResponse{
+		AccountID:    account.ID,
+		CurrencyCode: account.CurrencyCode,
+		Balance:      formatAmount(balance, account.CurrencyCode),
+		At:           req.At,
+	}))
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+const defaultHistoryPageSize = 10
+
+// GET /api/v1/accounts/:id/entries?limit=10
+// GET /api/v1/accounts/:id/entries?limit=10&cursor=last_id_from_previous_fetch&direction=debit&from=2024-01-01T00:00:00Z
+type accountHistoryRequest struct {
+	Limit     int32     `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor    int64     `form:"cursor" binding:"omitempty,min=0"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=debit credit"`
+	From      time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinAmount string    `form:"min_amount" binding:"omitempty,amount"`
+	MaxAmount string    `form:"max_amount" binding:"omitempty,amount"`
+}
+
+// accountHistoryFilter is an accountHistoryRequest with its amounts in minor units of the account's currency
+type accountHistoryFilter struct {
+	accountHistoryRequest
+	MinAmount int64
+	MaxAmount int64
+}
+
+type listAccountEntriesResponse struct {
+	Entries []entryResponse `json:"entries"`
+	Cursor  int64           `json:"cursor"`
+}
+
+type listAccountTransfersResponse struct {
+	Transfers []transferResponse `json:"transfers"`
+	Cursor    int64              `json:"cursor"`
+}
+
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	account, req, valid := server.bindAccountHistoryRequest(ctx)
+	if !valid {
+		return
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+		AccountID:   account.ID,
+		Cursor:      req.Cursor,
+		DebitCredit: pgtype.Text{String: req.Direction, Valid: req.Direction != ""},
+		FromDate:    pgtype.Timestamptz{Time: req.From, Valid: !req.From.IsZero()},
+		ToDate:      pgtype.Timestamptz{Time: req.To, Valid: !req.To.IsZero()},
+		MinAmount:   pgtype.Int8{Int64: req.MinAmount, Valid: req.MinAmount > 0},
+		MaxAmount:   pgtype.Int8{Int64: req.MaxAmount, Valid: req.MaxAmount > 0},
+		PageSize:    req.Limit,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listAccountEntriesResponse{
+		Entries: make([]entryResponse, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		rsp.Entries = append(rsp.Entries, newEntryResponse(entry, account.CurrencyCode))
+	}
+
+	if len(entries) > 0 {
+		rsp.Cursor = entries[len(entries)-1].ID // lastID
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	account, req, valid := server.bindAccountHistoryRequest(ctx)
+	if !valid {
+		return
+	}
+
+	transfers, err := server.store.ListAccountTransfers(ctx, db.ListAccountTransfersParams{
+		AccountID: account.ID,
+		Cursor:    req.Cursor,
+		Direction: pgtype.Text{String: req.Direction, Valid: req.Direction != ""},
+		FromDate:  pgtype.Timestamptz{Time: req.From, Valid: !req.From.IsZero()},
+		ToDate:    pgtype.Timestamptz{Time: req.To, Valid: !req.To.IsZero()},
+		MinAmount: pgtype.Int8{Int64: req.MinAmount, Valid: req.MinAmount > 0},
+		MaxAmount: pgtype.Int8{Int64: req.MaxAmount, Valid: req.MaxAmount > 0},
+		PageSize:  req.Limit,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the other side of a transfer can be in another currency
+	counterpartyIDs := make([]int64, 0, len(transfers))
+	for _, transfer := range transfers {
+		counterpartyIDs = append(counterpartyIDs, transfer.FromAccountID, transfer.ToAccountID)
+	}
+
+	currencyCodes, err := server.accountCurrencyCodes(ctx, counterpartyIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listAccountTransfersResponse{
+		Transfers: make([]transferResponse, 0, len(transfers)),
+	}
+	for _, transfer := range transfers {
+		rsp.Transfers = append(rsp.Transfers, newTransferResponse(transfer,
+			currencyCodes[transfer.FromAccountID], currencyCodes[transfer.ToAccountID]))
+	}
+
+	if len(transfers) > 0 {
+		rsp.Cursor = transfers[len(transfers)-1].ID // lastID
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+// bindAccountHistoryRequest validates the history filters and checks the account belongs to the caller
+func (server *Server) bindAccountHistoryRequest(ctx *gin.Context) (db.Account, accountHistoryFilter, bool) {
+	var uri getAccountRequest
+	var req accountHistoryFilter
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Account{}, req, false
+	}
+
+	if err := ctx.ShouldBindQuery(&req.accountHistoryRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Account{}, req, false
+	}
+
+	// Set default
+	if req.Limit <= 0 {
+		req.Limit = defaultHistoryPageSize
+	}
+
+	if !req.From.IsZero() && !req.To.IsZero() && !req.From.Before(req.To) {
+		err := errors.New("from must be before to")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Account{}, req, false
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return account, req, false
+	}
+
+	// amounts are in the account's currency
+	req.MinAmount, valid = parseOptionalAmount(ctx, req.accountHistoryRequest.MinAmount, account.CurrencyCode)
+	if !valid {
+		return account, req, false
+	}
+
+	req.MaxAmount, valid = parseOptionalAmount(ctx, req.accountHistoryRequest.MaxAmount, account.CurrencyCode)
+	if !valid {
+		return account, req, false
+	}
+
+	if req.MinAmount > 0 && req.MaxAmount > 0 && req.MinAmount > req.MaxAmount {
+		err := errors.New("min_amount must not be greater than max_amount")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, req, false
+	}
+
+	return account, req, true
+}
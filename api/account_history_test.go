@@ -0,0 +1,311 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/token"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	mockwk "github.com/kelvinator07/golang-bank-microservices/worker/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.ID)
+
+	n := 5
+	entries := make([]db.Entry, n)
+	for i := 0; i < n; i++ {
+		entries[i] = randomEntry(account.ID, int64(i+1))
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					PageSize:  defaultHistoryPageSize,
+				}
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				expected := listAccountEntriesResponse{Cursor: entries[n-1].ID}
+				for _, entry := range entries {
+					expected.Entries = append(expected.Entries, newEntryResponse(entry, account.CurrencyCode))
+				}
+				requireBodyMatchData(t, recorder.Body.Bytes(), expected)
+			},
+		},
+		{
+			name: "Filters",
+			query: url.Values{
+				"limit":      {"5"},
+				"cursor":     {"20"},
+				"direction":  {"debit"},
+				"from":       {from.Format(time.RFC3339)},
+				"to":         {to.Format(time.RFC3339)},
+				"min_amount": {"0.10"},
+				"max_amount": {"1"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				arg := db.ListAccountEntriesParams{
+					AccountID:   account.ID,
+					Cursor:      20,
+					DebitCredit: pgtype.Text{String: "debit", Valid: true},
+					FromDate:    pgtype.Timestamptz{Time: from, Valid: true},
+					ToDate:      pgtype.Timestamptz{Time: to, Valid: true},
+					MinAmount:   pgtype.Int8{Int64: 10, Valid: true},
+					MaxAmount:   pgtype.Int8{Int64: 100, Valid: true},
+					PageSize:    5,
+				}
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Entry{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "TooManyDecimalPlaces",
+			query: url.Values{"min_amount": {"0.001"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidDirection",
+			query: url.Values{"direction": {"sideways"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidDateRange",
+			query: url.Values{
+				"from": {to.Format(time.RFC3339)},
+				"to":   {from.Format(time.RFC3339)},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Unauthorized User ID",
+			query: url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, 222, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Entry{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/accounts/%d/entries?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAccountTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.ID)
+
+	account.CurrencyCode = util.USD
+	counterparty := randomAccount(user.ID + 1)
+	counterparty.ID = account.ID + 1
+	counterparty.CurrencyCode = util.NGN
+
+	transfers := []db.Transfer{
+		{ID: 1, FromAccountID: account.ID, ToAccountID: counterparty.ID, Amount: 100, ToAmount: 150000},
+		{ID: 2, FromAccountID: counterparty.ID, ToAccountID: account.ID, Amount: 300000, ToAmount: 200},
+	}
+
+	storeCtrl := gomock.NewController(t)
+	defer storeCtrl.Finish()
+
+	store := mockdb.NewMockStore(storeCtrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(account, nil)
+
+	arg := db.ListAccountTransfersParams{
+		AccountID: account.ID,
+		Direction: pgtype.Text{String: "credit", Valid: true},
+		PageSize:  defaultHistoryPageSize,
+	}
+	store.EXPECT().
+		ListAccountTransfers(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(transfers, nil)
+	store.EXPECT().
+		ListAccountsByIDs(gomock.Any(), gomock.Eq([]int64{account.ID, counterparty.ID, counterparty.ID, account.ID})).
+		Times(1).
+		Return([]db.Account{account, counterparty}, nil)
+
+	workerCtrl := gomock.NewController(t)
+	defer workerCtrl.Finish()
+
+	worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+	server := newTestServer(t, store, worker)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/api/v1/accounts/%d/transfers?direction=credit", account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var res struct {
+		Data listAccountTransfersResponse `json:"data"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res.Data.Cursor)
+
+	// each amount is in the currency of its own side of the transfer
+	assert.Len(t, res.Data.Transfers, 2)
+	assert.Equal(t, "1.00", res.Data.Transfers[0].Amount)
+	assert.Equal(t, "1500.00", res.Data.Transfers[0].ToAmount)
+	assert.Equal(t, "3000.00", res.Data.Transfers[1].Amount)
+	assert.Equal(t, "2.00", res.Data.Transfers[1].ToAmount)
+}
+
+func randomEntry(accountID int64, id int64) db.Entry {
+	return db.Entry{
+		ID:          id,
+		AccountID:   accountID,
+		Amount:      util.RandomMoney(),
+		DebitCredit: "credit",
+	}
+}
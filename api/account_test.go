@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kelvinator07/golang-bank-microservices/accountnumber"
 	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
 	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
 	"github.com/kelvinator07/golang-bank-microservices/token"
@@ -20,7 +25,221 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
-// TODO TEST CREATE ACCOUNT
+func TestCreateAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	// the account number the store was last asked to create an account with
+	var accountNumber string
+	createAccount := func(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+		accountNumber = arg.AccountNumber
+		return db.Account{
+			ID:            1,
+			UserID:        arg.UserID,
+			AccountNumber: arg.AccountNumber,
+			Status:        arg.Status,
+			CurrencyCode:  arg.CurrencyCode,
+			Kind:          db.AccountKindCustomer,
+		}, nil
+	}
+	accountNumberTaken := &pgconn.PgError{Code: db.UniqueViolation, ConstraintName: db.AccountNumberIndex}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"currency_code": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(createAccount)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.True(t, accountnumber.ValidNUBAN("999", accountNumber))
+
+				var res accountResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				assert.NoError(t, err)
+				assert.Equal(t, accountNumber, res.AccountNumber)
+				assert.Equal(t, db.AccountStatusInactive, res.Status)
+				assert.Equal(t, "0.00", res.AvailableBalance)
+			},
+		},
+		{
+			name: "AccountNumberTaken",
+			body: gin.H{"currency_code": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, accountNumberTaken),
+					store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(createAccount),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.True(t, accountnumber.ValidNUBAN("999", accountNumber))
+			},
+		},
+		{
+			name: "NoAccountNumberLeft",
+			body: gin.H{"currency_code": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(maxAccountNumberAttempts).
+					Return(db.Account{}, accountNumberTaken)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyTaken",
+			body: gin.H{"currency_code": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCurrency",
+			body: gin.H{"currency_code": "XYZ"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/accounts", bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetAccountByNumberAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = 1
+	owner, _ := randomUser(t)
+	owner.ID = 2
+	account := randomActiveAccount(owner.ID, 3, util.NGN)
+	account.AccountNumber = "0000014579"
+
+	testCases := []struct {
+		name          string
+		number        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			number: account.AccountNumber,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.AccountNumber)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(owner.ID)).Times(1).Return(owner, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchData(t, recorder.Body.Bytes(), accountLookupResponse{
+					ID:            account.ID,
+					AccountNumber: account.AccountNumber,
+					AccountName:   owner.AccountName,
+					CurrencyCode:  util.NGN,
+					Status:        db.AccountStatusActive,
+				})
+			},
+		},
+		{
+			name:   "NotFound",
+			number: "0000000001",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq("0000000001")).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "TooShort",
+			number: "14579",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "NotDigits",
+			number: "-000014579",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/accounts/by-number/%s", tc.number)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
 
 func TestGetAccountAPI(t *testing.T) {
 	user, _ := randomUser(t)
@@ -44,6 +263,10 @@ func TestGetAccountAPI(t *testing.T) {
 					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 					Times(1).
 					Return(account, nil)
+				store.EXPECT().
+					GetAccountHeldAmount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(int64(0), nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusOK, recorder.Code)
@@ -165,8 +388,10 @@ func TestGetAllAccountsAPI(t *testing.T) {
 
 	n := 5
 	accounts := make([]db.Account, n)
+	accountIDs := make([]int64, n)
 	for i := 0; i < n; i++ {
 		accounts[i] = randomAccount(user.ID)
+		accountIDs[i] = accounts[i].ID
 	}
 
 	type Query struct {
@@ -192,7 +417,7 @@ func TestGetAllAccountsAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.ListAccountsParams{
-					UserID: user.ID,
+					UserID: pgtype.Int8{Int64: user.ID, Valid: true},
 					Limit:  int32(n),
 					Offset: 0,
 				}
@@ -201,10 +426,38 @@ func TestGetAllAccountsAPI(t *testing.T) {
 					ListAccounts(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
 					Return(accounts, nil)
+				store.EXPECT().
+					ListAccountHeldAmounts(gomock.Any(), gomock.Eq(accountIDs)).
+					Times(1).
+					Return([]db.ListAccountHeldAmountsRow{{AccountID: accounts[0].ID, HeldAmount: 100}}, nil)
+				store.EXPECT().GetAccountHeldAmount(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchAccounts(t, recorder.Body, accounts)
+				requireBodyMatchAccounts(t, recorder.Body, accounts, map[int64]int64{accounts[0].ID: 100})
+			},
+		},
+		{
+			name: "HeldAmountsError",
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(accounts, nil)
+				store.EXPECT().
+					ListAccountHeldAmounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 		{
@@ -316,33 +569,185 @@ func TestGetAllAccountsAPI(t *testing.T) {
 	}
 }
 
+func TestUpdateAccountStatusAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.ID)
+
+	testCases := []struct {
+		name          string
+		action        string
+		status        string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Activate",
+			action: "activate",
+			status: db.AccountStatusInactive,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountStatusActive,
+					ChangedBy: user.ID,
+					Reason:    "",
+				}
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "ActivateFrozenAccount",
+			action: "activate",
+			status: db.AccountStatusFrozen,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "CloseWithBalance",
+			action: "close",
+			status: db.AccountStatusActive,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrAccountBalanceNotZero)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidTransition",
+			action: "close",
+			status: db.AccountStatusClosed,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateAccountStatusTxResult{}, db.ErrInvalidStatusTransition)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "Unauthorized User ID",
+			action: "close",
+			status: db.AccountStatusActive,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, 222, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			current := account
+			current.Status = tc.status
+
+			store := mockdb.NewMockStore(storeCtrl)
+			store.EXPECT().
+				GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+				Times(1).
+				Return(current, nil)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/accounts/%d/%s", account.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 func randomAccount(userID int64) db.Account {
 	return db.Account{
 		ID:            util.RandomInt(1, 1000),
-		UserID:        userID,
+		UserID:        pgtype.Int8{Int64: userID, Valid: true},
 		AccountNumber: util.RandomAccountNumber(),
 		Status:        util.RandomStatus(),
 		Balance:       util.RandomMoney(),
 		CurrencyCode:  util.RandomCurrency(),
+		Kind:          db.AccountKindCustomer,
 	}
 }
 
+// requiredBodyMatchAccount expects the account as getAccount renders it, with nothing on hold
 func requiredBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Account) {
 	data, err := io.ReadAll(body)
 	assert.NoError(t, err)
 
-	var expectedAccount db.Account
-	err = json.Unmarshal(data, &expectedAccount)
+	expected, err := json.Marshal(availableAccount(account))
 	assert.NoError(t, err)
-	assert.Equal(t, account, expectedAccount)
+	assert.JSONEq(t, string(expected), string(data))
 }
 
-func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Account) {
+func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Account, held map[int64]int64) {
 	data, err := io.ReadAll(body)
 	assert.NoError(t, err)
 
-	var gotAccounts []db.Account
-	err = json.Unmarshal(data, &gotAccounts)
+	rsp := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		accountRsp := newAccountResponse(account)
+		accountRsp.AvailableBalance = formatAmount(db.AvailableBalance(account, held[account.ID]), account.CurrencyCode)
+		rsp = append(rsp, accountRsp)
+	}
+
+	expected, err := json.Marshal(rsp)
 	assert.NoError(t, err)
-	assert.Equal(t, accounts, gotAccounts)
+	assert.JSONEq(t, string(expected), string(data))
+}
+
+func availableAccount(account db.Account) accountResponse {
+	rsp := newAccountResponse(account)
+	rsp.AvailableBalance = formatAmount(db.AvailableBalance(account, 0), account.CurrencyCode)
+	return rsp
 }
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+type setOverdraftLimitRequest struct {
+	OverdraftLimit string `json:"overdraft_limit" binding:"required,decimal"`
+}
+
+// setOverdraftLimit lets an admin give an account a credit line, zero takes it away.
+// Lowering the limit below what is already used doesn't touch the balance, the account just can't spend more
+func (server *Server) setOverdraftLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setOverdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.anyAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	// the limit is in the account's currency, which decides how many decimal places it may have
+	overdraftLimit, valid := parseAmount(ctx, req.OverdraftLimit, account.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	account, err := server.store.UpdateAccountOverdraftLimit(ctx, db.UpdateAccountOverdraftLimitParams{
+		ID:             account.ID,
+		OverdraftLimit: overdraftLimit,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.availableAccountResponse(ctx, account)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+// freezeAccount stops an account from moving money, only an admin can freeze or unfreeze it
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusActive, db.AccountStatusFrozen, server.anyAccount)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, db.AccountStatusFrozen, db.AccountStatusActive, server.anyAccount)
+}
+
+// anyAccount fetches an account whoever owns it, for the routes only admins reach
+func (server *Server) anyAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("account with id %v doesnt exist", accountID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+type setUserTierUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type setUserTierRequest struct {
+	Tier string `json:"tier" binding:"required,oneof=standard premium"`
+}
+
+// setUserTier moves a user to another tier, their transfer limits change straight away
+func (server *Server) setUserTier(ctx *gin.Context) {
+	var uri setUserTierUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setUserTierRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserTier(ctx, db.UpdateUserTierParams{
+		ID:   uri.ID,
+		Tier: req.Tier,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("user with id %v doesnt exist", uri.ID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newUserResponse(user)))
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+// listCurrencies lists every currency in the registry, disabled ones included
+func (server *Server) listCurrencies(ctx *gin.Context) {
+	currencies, err := server.store.ListCurrencies(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(currencies))
+}
+
+type createCurrencyRequest struct {
+	Code       string `json:"code" binding:"required,len=3,alpha,uppercase"`
+	MinorUnits *int32 `json:"minor_units" binding:"required,min=0,max=4"`
+	Symbol     string `json:"symbol" binding:"required,max=8"`
+	Enabled    *bool  `json:"enabled"`
+}
+
+// createCurrency adds a currency to the registry, it is enabled unless the request says otherwise
+func (server *Server) createCurrency(ctx *gin.Context) {
+	var req createCurrencyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	currency, err := server.store.CreateCurrencyTx(ctx, db.CreateCurrencyParams{
+		Code:       req.Code,
+		MinorUnits: *req.MinorUnits,
+		Symbol:     req.Symbol,
+		Enabled:    enabled,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			err = fmt.Errorf("currency %s already exists", req.Code)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	util.RegisterCurrency(currency.RegistryCurrency())
+	ctx.JSON(http.StatusOK, validResponse(currency))
+}
+
+type updateCurrencyUriRequest struct {
+	Code string `uri:"code" binding:"required,len=3,alpha,uppercase"`
+}
+
+// minor units can't be changed, amounts already stored in the currency would change value
+type updateCurrencyRequest struct {
+	Symbol  *string `json:"symbol" binding:"omitempty,min=1,max=8"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// updateCurrency changes the symbol of a currency or enables and disables it. Accounts in a disabled currency
+// are kept, but no new ones can be opened and no money moved in it until it is enabled again
+func (server *Server) updateCurrency(ctx *gin.Context) {
+	var uri updateCurrencyUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateCurrencyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateCurrencyParams{
+		Code: uri.Code,
+	}
+	if req.Symbol != nil {
+		arg.Symbol = pgtype.Text{String: *req.Symbol, Valid: true}
+	}
+	if req.Enabled != nil {
+		arg.Enabled = pgtype.Bool{Bool: *req.Enabled, Valid: true}
+	}
+
+	currency, err := server.store.UpdateCurrency(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("currency %s doesnt exist", uri.Code)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	util.RegisterCurrency(currency.RegistryCurrency())
+	ctx.JSON(http.StatusOK, validResponse(currency))
+}
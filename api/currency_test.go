@@ -0,0 +1,291 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	mockwk "github.com/kelvinator07/golang-bank-microservices/worker/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateCurrencyAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.ID = 1
+	admin.Role = db.UserRoleAdmin
+
+	currency := db.Currency{
+		Code:       "XTS",
+		MinorUnits: 3,
+		Symbol:     "¤",
+		Enabled:    true,
+	}
+
+	registered := util.Currencies()
+	t.Cleanup(func() { util.SetCurrencies(registered) })
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"code":        currency.Code,
+				"minor_units": currency.MinorUnits,
+				"symbol":      currency.Symbol,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateCurrencyParams{
+					Code:       currency.Code,
+					MinorUnits: currency.MinorUnits,
+					Symbol:     currency.Symbol,
+					Enabled:    true,
+				}
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(currency, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchData(t, recorder.Body.Bytes(), currency)
+
+				assert.True(t, util.IsEnabledCurrency(currency.Code))
+				assert.Equal(t, 3, util.CurrencyFor(currency.Code).MinorUnits)
+			},
+		},
+		{
+			name: "ZeroMinorUnits",
+			body: gin.H{
+				"code":        "JPY",
+				"minor_units": 0,
+				"symbol":      "¥",
+				"enabled":     false,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateCurrencyParams{
+					Code:       "JPY",
+					MinorUnits: 0,
+					Symbol:     "¥",
+					Enabled:    false,
+				}
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Currency{Code: "JPY", Symbol: "¥"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				assert.False(t, util.IsEnabledCurrency("JPY"))
+				assert.Equal(t, "¥1500", util.NewMoney(1500, "JPY").Display())
+			},
+		},
+		{
+			name: "MissingMinorUnits",
+			body: gin.H{
+				"code":   currency.Code,
+				"symbol": currency.Symbol,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyMinorUnits",
+			body: gin.H{
+				"code":        currency.Code,
+				"minor_units": 5,
+				"symbol":      currency.Symbol,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{
+				"code":        "xts",
+				"minor_units": currency.MinorUnits,
+				"symbol":      currency.Symbol,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyExists",
+			body: gin.H{
+				"code":        util.USD,
+				"minor_units": 2,
+				"symbol":      "$",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateCurrencyTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Currency{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.ID)).Times(1).Return(admin, nil)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/admin/currencies", bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.ID, admin.AccountName, admin.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateCurrencyAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.ID = 1
+	admin.Role = db.UserRoleAdmin
+
+	registered := util.Currencies()
+	t.Cleanup(func() { util.SetCurrencies(registered) })
+
+	testCases := []struct {
+		name          string
+		code          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Disable",
+			code: util.NGN,
+			body: gin.H{"enabled": false},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateCurrencyParams{
+					Code:    util.NGN,
+					Enabled: pgtype.Bool{Bool: false, Valid: true},
+				}
+				store.EXPECT().
+					UpdateCurrency(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.Currency{Code: util.NGN, MinorUnits: 2, Symbol: "₦", Enabled: false}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				// existing NGN amounts can still be parsed and rendered, but the currency can't be used in requests
+				assert.False(t, util.IsEnabledCurrency(util.NGN))
+				assert.Equal(t, "₦1.00", util.NewMoney(100, util.NGN).Display())
+			},
+		},
+		{
+			name: "Symbol",
+			code: util.EUR,
+			body: gin.H{"symbol": "EUR "},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateCurrencyParams{
+					Code:   util.EUR,
+					Symbol: pgtype.Text{String: "EUR ", Valid: true},
+				}
+				store.EXPECT().
+					UpdateCurrency(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.Currency{Code: util.EUR, MinorUnits: 2, Symbol: "EUR ", Enabled: true}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, "EUR 1.00", util.NewMoney(100, util.EUR).Display())
+			},
+		},
+		{
+			name: "NotFound",
+			code: "XTS",
+			body: gin.H{"enabled": true},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateCurrency(gomock.Any(), gomock.Any()).Times(1).Return(db.Currency{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			code: "usd",
+			body: gin.H{"enabled": true},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateCurrency(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.ID)).Times(1).Return(admin, nil)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/currencies/%s", tc.code)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.ID, admin.AccountName, admin.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
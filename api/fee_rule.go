@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+type createFeeRuleRequest struct {
+	CurrencyCode  string `json:"currency_code" binding:"required,currencyCode"`
+	MinAmount     string `json:"min_amount" binding:"omitempty,decimal"`
+	FlatFee       string `json:"flat_fee" binding:"omitempty,decimal"`
+	PercentageBps int64  `json:"percentage_bps" binding:"min=0,max=10000"`
+}
+
+type feeRuleResponse struct {
+	db.FeeRule
+	MinAmount string `json:"min_amount"`
+	FlatFee   string `json:"flat_fee"`
+}
+
+func newFeeRuleResponse(rule db.FeeRule) feeRuleResponse {
+	return feeRuleResponse{
+		FeeRule:   rule,
+		MinAmount: formatAmount(rule.MinAmount, rule.CurrencyCode),
+		FlatFee:   formatAmount(rule.FlatFee, rule.CurrencyCode),
+	}
+}
+
+// createFeeRule adds a fee rule for a currency. A rule applies from its min amount up to the next rule's,
+// so a tiered schedule is made of several rules
+func (server *Server) createFeeRule(ctx *gin.Context) {
+	var req createFeeRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// either amount can be left out, a rule without min_amount applies to any transfer
+	minAmount, valid := parseOptionalAmount(ctx, req.MinAmount, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	flatFee, valid := parseOptionalAmount(ctx, req.FlatFee, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	rule, err := server.store.CreateFeeRule(ctx, db.CreateFeeRuleParams{
+		CurrencyCode:  req.CurrencyCode,
+		MinAmount:     minAmount,
+		FlatFee:       flatFee,
+		PercentageBps: req.PercentageBps,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			err = errors.New("a fee rule with this currency and min amount already exists")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newFeeRuleResponse(rule)))
+}
+
+func (server *Server) listFeeRules(ctx *gin.Context) {
+	rules, err := server.store.ListFeeRules(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]feeRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		rsp = append(rsp, newFeeRuleResponse(rule))
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+type deleteFeeRuleRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteFeeRule(ctx *gin.Context) {
+	var req deleteFeeRuleRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rule, err := server.store.DeleteFeeRule(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("fee rule with id %v doesnt exist", req.ID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newFeeRuleResponse(rule)))
+}
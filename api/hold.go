@@ -0,0 +1,292 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/token"
+	"github.com/kelvinator07/golang-bank-microservices/worker"
+)
+
+const (
+	defaultHoldDuration = 7 * 24 * time.Hour
+	maxHoldDuration     = 30 * 24 * time.Hour
+)
+
+type placeHoldRequest struct {
+	AccountID    int64      `json:"account_id" binding:"required,min=1"`
+	ToAccountID  int64      `json:"to_account_id" binding:"required,min=1,nefield=AccountID"`
+	Amount       string     `json:"amount" binding:"required,amount"`
+	CurrencyCode string     `json:"currency_code" binding:"required,currencyCode"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+type holdResponse struct {
+	db.Hold
+	Amount         string `json:"amount"`
+	CapturedAmount string `json:"captured_amount"`
+}
+
+func newHoldResponse(hold db.Hold) holdResponse {
+	return holdResponse{
+		Hold:           hold,
+		Amount:         formatAmount(hold.Amount, hold.CurrencyCode),
+		CapturedAmount: formatAmount(hold.CapturedAmount, hold.CurrencyCode),
+	}
+}
+
+type placeHoldResponse struct {
+	Hold             holdResponse `json:"hold"`
+	AvailableBalance string       `json:"available_balance"`
+}
+
+// placeHold reserves funds on the authenticated user's account for a later capture into to_account_id
+func (server *Server) placeHold(ctx *gin.Context) {
+	var req placeHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	expiresAt := time.Now().Add(defaultHoldDuration)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) || req.ExpiresAt.After(time.Now().Add(maxHoldDuration)) {
+			err := fmt.Errorf("expires_at must be in the future and within %v", maxHoldDuration)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	amount, valid := parseAmount(ctx, req.Amount, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	account, valid := server.validAccount(ctx, req.AccountID, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !account.OwnedBy(authPayload.UserID) {
+		err := errors.New("account doesnt belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	arg := db.PlaceHoldTxParams{
+		CreateHoldParams: db.CreateHoldParams{
+			AccountID:    req.AccountID,
+			ToAccountID:  req.ToAccountID,
+			Amount:       amount,
+			CurrencyCode: req.CurrencyCode,
+			ExpiresAt:    expiresAt,
+		},
+		AfterCreate: func(hold db.Hold) error {
+			taskPayload := &worker.PayloadExpireHold{HoldID: hold.ID}
+
+			opts := []asynq.Option{
+				asynq.MaxRetry(10),
+				asynq.ProcessAt(hold.ExpiresAt),
+				asynq.Queue(worker.QueueDefault),
+			}
+			return server.taskDistributor.DistributeTaskExpireHold(ctx, taskPayload, opts...)
+		},
+	}
+
+	result, err := server.store.PlaceHoldTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(placeHoldResponse{
+		Hold:             newHoldResponse(result.Hold),
+		AvailableBalance: formatAmount(result.AvailableBalance, result.Hold.CurrencyCode),
+	}))
+}
+
+type getHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getHold(ctx *gin.Context) {
+	var req getHoldRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.ownedHold(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newHoldResponse(hold)))
+}
+
+type captureHoldRequest struct {
+	Amount string `json:"amount" binding:"omitempty,amount"`
+}
+
+type captureHoldResponse struct {
+	Hold holdResponse `json:"hold"`
+	transferTxResponse
+}
+
+// captureHold settles all or part of a hold, the part that isn't captured goes back to the available balance
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri getHoldRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the amount is optional, an empty body captures the whole hold
+	var req captureHoldRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	hold, valid := server.ownedHold(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	amount, valid := parseOptionalAmount(ctx, req.Amount, hold.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{
+		HoldID: hold.ID,
+		Amount: amount,
+	})
+	if err != nil {
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(http.StatusBadRequest, transferLimitErrorResponse(limitErr))
+			return
+		}
+		if errors.Is(err, db.ErrHoldNotActive) || errors.Is(err, db.ErrCaptureAmountTooLarge) ||
+			errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrCurrencyMismatch) ||
+			errors.Is(err, db.ErrAmountTooLarge) || errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrCurrencyDisabled) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.notifyOverdraft(ctx, result.TransferTxResult)
+
+	ctx.JSON(http.StatusOK, validResponse(captureHoldResponse{
+		Hold:               newHoldResponse(result.Hold),
+		transferTxResponse: newTransferTxResponse(result.TransferTxResult),
+	}))
+}
+
+func (server *Server) voidHold(ctx *gin.Context) {
+	var req getHoldRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, valid := server.ownedHold(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	hold, err := server.store.VoidHoldTx(ctx, hold.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrHoldNotActive) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newHoldResponse(hold)))
+}
+
+type listAccountHoldsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listAccountHolds(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listAccountHoldsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	holds, err := server.store.ListAccountHolds(ctx, db.ListAccountHoldsParams{
+		AccountID: account.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]holdResponse, 0, len(holds))
+	for _, hold := range holds {
+		rsp = append(rsp, newHoldResponse(hold))
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+// ownedHold fetches a hold placed on one of the authenticated user's accounts
+func (server *Server) ownedHold(ctx *gin.Context, holdID int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, holdID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("hold with id %v doesnt exist", holdID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+
+	if _, valid := server.ownedAccount(ctx, hold.AccountID); !valid {
+		return hold, false
+	}
+
+	return hold, true
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+type ledgerRequest struct {
+	AccountID    int64  `json:"account_id" binding:"required,min=1"`
+	Amount       string `json:"amount" binding:"required,amount"`
+	CurrencyCode string `json:"currency_code" binding:"required,currencyCode"`
+}
+
+// deposit credits a customer account with money paid into the bank, against the funding account of its currency
+func (server *Server) deposit(ctx *gin.Context) {
+	server.postLedger(ctx, server.store.DepositTx)
+}
+
+// withdraw debits a customer account with money paid out of the bank, against the withdrawal account of its currency
+func (server *Server) withdraw(ctx *gin.Context) {
+	server.postLedger(ctx, server.store.WithdrawTx)
+}
+
+func (server *Server) postLedger(ctx *gin.Context, post func(context.Context, db.LedgerTxParams) (db.TransferTxResult, error)) {
+	var req ledgerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	amount, valid := parseAmount(ctx, req.Amount, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	_, valid = server.validAccount(ctx, req.AccountID, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	result, err := post(ctx, db.LedgerTxParams{
+		AccountID: req.AccountID,
+		Amount:    amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrNotCustomerAccount) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.notifyOverdraft(ctx, result)
+
+	ctx.JSON(http.StatusOK, validResponse(newTransferTxResponse(result)))
+}
+
+// getTrialBalance totals the balances of every account by currency, each currency should come to zero.
+// Balances from before the ledger were posted to the funding accounts at the cut-over, migration 000025
+func (server *Server) getTrialBalance(ctx *gin.Context) {
+	rows, err := server.store.GetTrialBalance(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	balances := db.NewTrialBalances(rows)
+
+	rsp := make([]trialBalanceResponse, 0, len(balances))
+	for _, balance := range balances {
+		rsp = append(rsp, newTrialBalanceResponse(balance))
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+type trialBalanceLineResponse struct {
+	db.GetTrialBalanceRow
+	Balance string `json:"balance"`
+}
+
+type trialBalanceResponse struct {
+	db.TrialBalance
+	Lines []trialBalanceLineResponse `json:"lines"`
+	Total string                     `json:"total"`
+}
+
+func newTrialBalanceResponse(balance db.TrialBalance) trialBalanceResponse {
+	rsp := trialBalanceResponse{
+		TrialBalance: balance,
+		Lines:        make([]trialBalanceLineResponse, 0, len(balance.Lines)),
+		Total:        formatAmount(balance.Total, balance.CurrencyCode),
+	}
+	for _, line := range balance.Lines {
+		rsp.Lines = append(rsp.Lines, trialBalanceLineResponse{
+			GetTrialBalanceRow: line,
+			Balance:            formatAmount(line.Balance, line.CurrencyCode),
+		})
+	}
+	return rsp
+}
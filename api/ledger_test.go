@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	mockwk "github.com/kelvinator07/golang-bank-microservices/worker/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLedgerAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.ID = 1
+	admin.Role = db.UserRoleAdmin
+	customer, _ := randomUser(t)
+	customer.ID = 2
+
+	account := randomActiveAccount(customer.ID, 1, util.USD)
+	funding := randomActiveAccount(0, 2, util.USD)
+	funding.UserID.Valid = false
+	funding.Kind = db.AccountKindFunding
+
+	amount := int64(100)
+
+	testCases := []struct {
+		name          string
+		url           string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Deposit",
+			url:  "/api/v1/admin/deposits",
+			body: gin.H{"account_id": account.ID, "amount": "1.00", "currency_code": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Eq(db.LedgerTxParams{AccountID: account.ID, Amount: amount})).
+					Times(1).
+					Return(db.TransferTxResult{FromAccount: funding, ToAccount: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "WithdrawInsufficientFunds",
+			url:  "/api/v1/admin/withdrawals",
+			body: gin.H{"account_id": account.ID, "amount": "1.00", "currency_code": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					WithdrawTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "SystemAccount",
+			url:  "/api/v1/admin/deposits",
+			body: gin.H{"account_id": funding.ID, "amount": "1.00", "currency_code": util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(funding.ID)).Times(1).Return(funding, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			url:  "/api/v1/admin/deposits",
+			body: gin.H{"account_id": account.ID, "amount": "1.00", "currency_code": util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.ID)).Times(1).Return(admin, nil)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.ID, admin.AccountName, admin.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTrialBalanceAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.ID = 1
+	admin.Role = db.UserRoleAdmin
+
+	rows := []db.GetTrialBalanceRow{
+		{CurrencyCode: util.USD, Kind: db.AccountKindCustomer, Accounts: 3, Balance: 900},
+		{CurrencyCode: util.USD, Kind: db.AccountKindFunding, Accounts: 1, Balance: -900},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.ID)).Times(1).Return(admin, nil)
+	store.EXPECT().GetTrialBalance(gomock.Any()).Times(1).Return(rows, nil)
+
+	server := newTestServer(t, store, mockwk.NewMockTaskDistributor(ctrl))
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/admin/trial-balance", nil)
+	assert.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.ID, admin.AccountName, admin.Email, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var res struct {
+		Data []trialBalanceResponse `json:"data"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 1)
+	assert.True(t, res.Data[0].Balanced)
+	assert.Equal(t, "0.00", res.Data[0].Total)
+	assert.Equal(t, "9.00", res.Data[0].Lines[0].Balance)
+	assert.Equal(t, "-9.00", res.Data[0].Lines[1].Balance)
+}
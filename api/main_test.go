@@ -1,29 +1,60 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
 	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
 	"github.com/kelvinator07/golang-bank-microservices/util"
 	"github.com/kelvinator07/golang-bank-microservices/worker"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func newTestServer(t *testing.T, store db.Store, taskDistributor worker.TaskDistributor) *Server {
 	config := util.Env{
 		TokenSymmetricKey:   util.RandomString(32),
 		AccessTokenDuration: time.Minute,
+		BankCode:            "999",
 	}
 
 	server, err := NewServer(config, store, taskDistributor)
 	require.NoError(t, err)
 
+	// the access tokens addAuthorization creates belong to active sessions, TestAuthMiddleware covers the session checks
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			GetSessionAuth(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			DoAndReturn(func(_ context.Context, id uuid.UUID) (db.GetSessionAuthRow, error) {
+				return db.GetSessionAuthRow{ID: id, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			})
+	}
+
 	return server
 }
 
+// requireBodyMatchData compares the data of a validResponse with what expected encodes to,
+// response types shadow the amounts of the rows they embed so they can't be decoded back into them
+func requireBodyMatchData(t *testing.T, body []byte, expected any) {
+	var res struct {
+		Data json.RawMessage `json:"data"`
+	}
+	err := json.Unmarshal(body, &res)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(expected)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(res.Data))
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
@@ -1,13 +1,20 @@
 package api
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
 	"github.com/kelvinator07/golang-bank-microservices/token"
 )
 
@@ -15,8 +22,11 @@ const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authPayload"
+	idempotencyHeaderKey    = "Idempotency-Key"
 )
 
+const defaultIdempotencyKeyTTL = 24 * time.Hour
+
 func RequestLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		t := time.Now()
@@ -48,7 +58,9 @@ func ResponseLogger() gin.HandlerFunc {
 	}
 }
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// authMiddleware lets through access tokens whose session is still active and that were issued after the user's
+// password last changed, so logging out, revoking a session or changing the password cuts off its access tokens too
+func authMiddleware(tokenMaker token.Maker, sessions *sessionCache) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -78,7 +90,196 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
+		// refresh tokens aren't bound to a session, they can only be used to renew access tokens
+		if payload.SessionID == uuid.Nil {
+			err := errors.New("token isn't bound to a session")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		session, err := sessions.get(ctx, payload.SessionID)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				err = errors.New("session of the token doesn't exist")
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if session.IsBlocked {
+			err := errors.New("session of the token is blocked")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		if time.Now().After(session.ExpiresAt) {
+			err := errors.New("session of the token has expired")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		if payload.IssuedAt.Before(session.PasswordChangedAt) {
+			err := errors.New("token was issued before the password was changed")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+// adminMiddleware only lets admins through. The role is read from the database rather than the token,
+// so revoking it takes effect straight away. Must be registered after authMiddleware
+func adminMiddleware(store db.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		user, err := store.GetUser(ctx, authPayload.UserID)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if user.Role != db.UserRoleAdmin {
+			err := errors.New("only admins can access this resource")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// idempotencyResponseWriter keeps a copy of the response body so it can be replayed
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w idempotencyResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// idempotencyMiddleware honours the Idempotency-Key header. The first successful response for a key
+// is stored and replayed for retries with the same body, a retry with a different body is rejected.
+// Must be registered after authMiddleware since keys are scoped to the authenticated user.
+func idempotencyMiddleware(store db.Store, ttl time.Duration) gin.HandlerFunc {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyHeaderKey)
+		if len(key) == 0 {
+			ctx.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			err := errors.New("idempotency key must not be longer than 255 characters")
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+		// the actual path, not the route pattern, so a key reused on another transfer or hold is rejected
+		requestPath := ctx.Request.URL.Path
+
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		idempotencyKey, err := store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+			UserID: authPayload.UserID,
+			Key:    key,
+		})
+		if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if err == nil {
+			if time.Now().Before(idempotencyKey.ExpiredAt) {
+				if idempotencyKey.RequestPath != requestPath || idempotencyKey.RequestHash != requestHash {
+					err = errors.New("idempotency key was already used with a different request")
+					ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, errorResponse(err))
+					return
+				}
+
+				if idempotencyKey.ResponseCode == 0 {
+					err = errors.New("a request with this idempotency key is still being processed")
+					ctx.AbortWithStatusJSON(http.StatusConflict, errorResponse(err))
+					return
+				}
+
+				ctx.Data(int(idempotencyKey.ResponseCode), gin.MIMEJSON, idempotencyKey.ResponseBody)
+				ctx.Abort()
+				return
+			}
+
+			// expired key, the client is free to reuse it
+			if err = store.DeleteIdempotencyKey(ctx, idempotencyKey.ID); err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+		}
+
+		idempotencyKey, err = store.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+			UserID:      authPayload.UserID,
+			Key:         key,
+			RequestPath: requestPath,
+			RequestHash: requestHash,
+			ExpiredAt:   time.Now().Add(ttl),
+		})
+		if err != nil {
+			if db.ErrorCode(err) == db.UniqueViolation {
+				err = errors.New("a request with this idempotency key is still being processed")
+				ctx.AbortWithStatusJSON(http.StatusConflict, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		writer := idempotencyResponseWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		// only successful responses are replayed, anything else frees the key for a retry
+		status := writer.Status()
+		if status < http.StatusOK || status >= http.StatusMultipleChoices {
+			if err := store.DeleteIdempotencyKey(ctx, idempotencyKey.ID); err != nil {
+				log.Printf("failed to release idempotency key %d: %v", idempotencyKey.ID, err)
+			}
+			return
+		}
+
+		_, err = store.UpdateIdempotencyKeyResponse(ctx, db.UpdateIdempotencyKeyResponseParams{
+			ID:           idempotencyKey.ID,
+			ResponseCode: int32(status),
+			ResponseBody: writer.body.Bytes(),
+		})
+		if err != nil {
+			// a key left without a response would answer every retry with 409 until it expires, so it is freed
+			// instead and a retry runs the request again
+			log.Printf("failed to store idempotent response for key %d: %v", idempotencyKey.ID, err)
+			if err := store.DeleteIdempotencyKey(ctx, idempotencyKey.ID); err != nil {
+				log.Printf("failed to release idempotency key %d: %v", idempotencyKey.ID, err)
+			}
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package api
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +12,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
 	"github.com/kelvinator07/golang-bank-microservices/token"
 	mockwk "github.com/kelvinator07/golang-bank-microservices/worker/mock"
 	"github.com/stretchr/testify/assert"
@@ -24,7 +31,7 @@ func addAuthorization(
 	email string,
 	duration time.Duration,
 ) {
-	token, payload, err := tokenMaker.CreateToken(userID, accountName, email, duration)
+	token, payload, err := tokenMaker.CreateToken(userID, accountName, email, uuid.New(), duration)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, payload)
 
@@ -32,16 +39,35 @@ func addAuthorization(
 	request.Header.Set(authorizationHeaderKey, authorizationHeader)
 }
 
+// addSessionAuthorization adds an access token bound to sessionID
+func addSessionAuthorization(t *testing.T, request *http.Request, tokenMaker token.Maker, sessionID uuid.UUID) {
+	token, _, err := tokenMaker.CreateToken(1, "user", "user@gmail.com", sessionID, time.Minute)
+	assert.NoError(t, err)
+
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+}
+
 func TestAuthMiddleware(t *testing.T) {
+	sessionID := uuid.New()
+	activeSession := db.GetSessionAuthRow{
+		ID:        sessionID,
+		Email:     "user@gmail.com",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
 	testCases := []struct {
 		name          string
 		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, 1, "user", "user@gmail.com", time.Minute)
+				addSessionAuthorization(t, request, tokenMaker, sessionID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(activeSession, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusOK, recorder.Code)
@@ -51,6 +77,9 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "NoAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Any()).Times(0)
+			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
@@ -60,6 +89,9 @@ func TestAuthMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, "unsupported", 2, "user", "user@gmail.com", time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Any()).Times(0)
+			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
@@ -69,6 +101,9 @@ func TestAuthMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, "", 3, "user", "user@gmail.com", time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Any()).Times(0)
+			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
@@ -78,16 +113,103 @@ func TestAuthMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, "", 4, "user", "user@gmail.com", -time.Minute) // negative time
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addSessionAuthorization(t, request, tokenMaker, uuid.Nil)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addSessionAuthorization(t, request, tokenMaker, sessionID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(db.GetSessionAuthRow{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addSessionAuthorization(t, request, tokenMaker, sessionID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				session := activeSession
+				session.IsBlocked = true
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredSession",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addSessionAuthorization(t, request, tokenMaker, sessionID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				session := activeSession
+				session.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "IssuedBeforePasswordChange",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addSessionAuthorization(t, request, tokenMaker, sessionID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				session := activeSession
+				session.PasswordChangedAt = time.Now().Add(time.Minute)
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(session, nil)
+			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addSessionAuthorization(t, request, tokenMaker, sessionID)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(db.GetSessionAuthRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
 		tc := testCases[i]
 
 		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			tc.buildStubs(store)
+
 			workerCtrl := gomock.NewController(t)
 			defer workerCtrl.Finish()
 
@@ -99,7 +221,7 @@ func TestAuthMiddleware(t *testing.T) {
 			authUrl := "/api/v1/auth"
 			server.router.GET(
 				authUrl,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, newSessionCache(store, time.Minute)),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -115,3 +237,344 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddlewareSessionCache(t *testing.T) {
+	sessionID := uuid.New()
+	session := db.GetSessionAuthRow{
+		ID:        sessionID,
+		Email:     "user@gmail.com",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	storeCtrl := gomock.NewController(t)
+	defer storeCtrl.Finish()
+
+	store := mockdb.NewMockStore(storeCtrl)
+
+	blocked := session
+	blocked.IsBlocked = true
+	gomock.InOrder(
+		store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(session, nil),
+		store.EXPECT().GetSessionAuth(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(blocked, nil),
+	)
+
+	workerCtrl := gomock.NewController(t)
+	defer workerCtrl.Finish()
+
+	server := newTestServer(t, nil, mockwk.NewMockTaskDistributor(workerCtrl))
+	sessions := newSessionCache(store, time.Minute)
+
+	authUrl := "/api/v1/auth"
+	server.router.GET(authUrl, authMiddleware(server.tokenMaker, sessions), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	serve := func() int {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, authUrl, nil)
+		assert.NoError(t, err)
+
+		addSessionAuthorization(t, request, server.tokenMaker, sessionID)
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	// the second request is answered from the cache
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusOK, serve())
+
+	// once the user's sessions are forgotten the session is read again and found blocked
+	sessions.forgetUser(session.Email)
+	assert.Equal(t, http.StatusUnauthorized, serve())
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	idempotencyUrl := "/api/v1/idempotent"
+	body := []byte(`{"amount":10}`)
+	hash := sha256.Sum256(body)
+	requestHash := hex.EncodeToString(hash[:])
+	key := "7c1c4f62-3b6a-4bd5-9f3b-3b0b4c1b5b1e"
+	storedResponse := []byte(`{"status_code":"00","message":"Success","data":{"id":1}}`)
+
+	idempotencyKey := db.IdempotencyKey{
+		ID:          1,
+		UserID:      1,
+		Key:         key,
+		RequestPath: idempotencyUrl,
+		RequestHash: requestHash,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		key           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int)
+	}{
+		{
+			name: "NoKey",
+			key:  "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			name: "FirstRequest",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(db.GetIdempotencyKeyParams{UserID: 1, Key: key})).
+					Times(1).
+					Return(db.IdempotencyKey{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(idempotencyKey, nil)
+				store.EXPECT().
+					UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.UpdateIdempotencyKeyResponseParams) (db.IdempotencyKey, error) {
+						assert.Equal(t, idempotencyKey.ID, arg.ID)
+						assert.Equal(t, int32(http.StatusOK), arg.ResponseCode)
+						assert.JSONEq(t, string(storedResponse), string(arg.ResponseBody))
+						return idempotencyKey, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			name: "StoreResponseError",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(idempotencyKey, nil)
+				store.EXPECT().
+					UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, db.ErrUniqueViolation)
+				// the key is freed so a retry isn't turned away as still in flight
+				store.EXPECT().
+					DeleteIdempotencyKey(gomock.Any(), gomock.Eq(idempotencyKey.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			name: "Replay",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				replayed := idempotencyKey
+				replayed.ResponseCode = http.StatusOK
+				replayed.ResponseBody = storedResponse
+
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(replayed, nil)
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, 0, handlerCalls)
+				assert.JSONEq(t, string(storedResponse), recorder.Body.String())
+			},
+		},
+		{
+			name: "DifferentBody",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				used := idempotencyKey
+				used.RequestHash = "another-hash"
+				used.ResponseCode = http.StatusOK
+				used.ResponseBody = storedResponse
+
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(used, nil)
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				assert.Equal(t, 0, handlerCalls)
+			},
+		},
+		{
+			name: "InFlight",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(idempotencyKey, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				assert.Equal(t, 0, handlerCalls)
+			},
+		},
+		{
+			name: "ExpiredKey",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := idempotencyKey
+				expired.RequestHash = "another-hash"
+				expired.ExpiredAt = time.Now().Add(-time.Minute)
+
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(expired, nil)
+				store.EXPECT().
+					DeleteIdempotencyKey(gomock.Any(), gomock.Eq(expired.ID)).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(idempotencyKey, nil)
+				store.EXPECT().
+					UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(idempotencyKey, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+				assert.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			name: "ConcurrentCreate",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				assert.Equal(t, http.StatusConflict, recorder.Code)
+				assert.Equal(t, 0, handlerCalls)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+
+			handlerCalls := 0
+			server.router.POST(
+				idempotencyUrl,
+				authMiddleware(server.tokenMaker, server.sessions),
+				idempotencyMiddleware(server.store, time.Hour),
+				func(ctx *gin.Context) {
+					handlerCalls++
+					ctx.JSON(http.StatusOK, validResponse(gin.H{"id": 1}))
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, idempotencyUrl, bytes.NewReader(body))
+			assert.NoError(t, err)
+
+			if len(tc.key) > 0 {
+				request.Header.Set(idempotencyHeaderKey, tc.key)
+			}
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, 1, "user", "user@gmail.com", time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, handlerCalls)
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareDifferentResource(t *testing.T) {
+	body := []byte(`{"amount":"0.05"}`)
+	hash := sha256.Sum256(body)
+	key := "3f1b7a52-8c0e-4d3a-a1f4-0d2c9b6e7a10"
+
+	storeCtrl := gomock.NewController(t)
+	defer storeCtrl.Finish()
+
+	store := mockdb.NewMockStore(storeCtrl)
+
+	// the key was first used for resource 1
+	store.EXPECT().
+		GetIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.IdempotencyKey{
+			ID:           1,
+			UserID:       1,
+			Key:          key,
+			RequestPath:  "/api/v1/idempotent/1",
+			RequestHash:  hex.EncodeToString(hash[:]),
+			ResponseCode: http.StatusOK,
+			ResponseBody: []byte(`{"status_code":"00","message":"Success","data":{"id":1}}`),
+			ExpiredAt:    time.Now().Add(time.Hour),
+		}, nil)
+	store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+
+	workerCtrl := gomock.NewController(t)
+	defer workerCtrl.Finish()
+
+	server := newTestServer(t, store, mockwk.NewMockTaskDistributor(workerCtrl))
+
+	handlerCalls := 0
+	server.router.POST(
+		"/api/v1/idempotent/:id",
+		authMiddleware(server.tokenMaker, server.sessions),
+		idempotencyMiddleware(server.store, time.Hour),
+		func(ctx *gin.Context) {
+			handlerCalls++
+			ctx.JSON(http.StatusOK, validResponse(gin.H{"id": 2}))
+		},
+	)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/idempotent/2", bytes.NewReader(body))
+	assert.NoError(t, err)
+	request.Header.Set(idempotencyHeaderKey, key)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, 1, "user", "user@gmail.com", time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	// the same key and body on another resource must not replay the first resource's response
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, 0, handlerCalls)
+}
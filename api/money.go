@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+// amounts are sent and returned as decimal strings in the currency's major unit, e.g. "10.50" dollars,
+// they are stored as whole numbers of its minor unit
+
+var (
+	isDecimal = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`).MatchString
+	isNonZero = regexp.MustCompile(`[1-9]`).MatchString
+)
+
+// validDecimal accepts a decimal that isn't negative, how many decimal places it may have depends on the currency
+var validDecimal validator.Func = func(fl validator.FieldLevel) bool {
+	if amount, ok := fl.Field().Interface().(string); ok {
+		return isDecimal(amount)
+	}
+	return false
+}
+
+// validAmount accepts a decimal greater than zero
+var validAmount validator.Func = func(fl validator.FieldLevel) bool {
+	if amount, ok := fl.Field().Interface().(string); ok {
+		return isDecimal(amount) && isNonZero(amount)
+	}
+	return false
+}
+
+// parseAmount converts a decimal amount to minor units of the currency, it responds with 400 when the amount
+// has more decimal places than the currency
+func parseAmount(ctx *gin.Context, amount string, currencyCode string) (int64, bool) {
+	money, err := util.ParseMoney(amount, currencyCode)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return 0, false
+	}
+	return money.Amount, true
+}
+
+// parseOptionalAmount is parseAmount for amounts that can be left out, which count as zero
+func parseOptionalAmount(ctx *gin.Context, amount string, currencyCode string) (int64, bool) {
+	if amount == "" {
+		return 0, true
+	}
+	return parseAmount(ctx, amount, currencyCode)
+}
+
+// formatAmount renders minor units of the currency as a decimal, e.g. 1050 USD as "10.50"
+func formatAmount(amount int64, currencyCode string) string {
+	return util.NewMoney(amount, currencyCode).String()
+}
+
+// accountCurrencyCodes looks up the currency of each account, for rows that store amounts without one
+func (server *Server) accountCurrencyCodes(ctx context.Context, accountIDs []int64) (map[int64]string, error) {
+	accounts, err := server.store.ListAccountsByIDs(ctx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	currencyCodes := make(map[int64]string, len(accounts))
+	for _, account := range accounts {
+		currencyCodes[account.ID] = account.CurrencyCode
+	}
+	return currencyCodes, nil
+}
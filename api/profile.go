@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/token"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	"github.com/kelvinator07/golang-bank-microservices/worker"
+)
+
+type updateProfileRequest struct {
+	Address     *string `json:"address" binding:"omitempty,min=5"`
+	PhoneNumber *int64  `json:"phone_number" binding:"omitempty,min=1"`
+}
+
+// updateProfile changes the authenticated user's address and phone number, fields left out keep their value
+func (server *Server) updateProfile(ctx *gin.Context) {
+	var req updateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Address == nil && req.PhoneNumber == nil {
+		err := errors.New("address or phone_number is required")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.UpdateUserParams{
+		Email: pgtype.Text{
+			String: authPayload.Email,
+			Valid:  true,
+		},
+	}
+	if req.Address != nil {
+		arg.Address = pgtype.Text{String: *req.Address, Valid: true}
+	}
+	if req.PhoneNumber != nil {
+		arg.PhoneNumber = pgtype.Int8{Int64: *req.PhoneNumber, Valid: true}
+	}
+
+	user, err := server.store.UpdateUser(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("user with email %s doesn't exist", authPayload.Email)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		// only the phone number is unique among the fields that can change
+		if req.PhoneNumber != nil && db.ErrorCode(err) == db.UniqueViolation {
+			err = fmt.Errorf("phone number %d is already in use", *req.PhoneNumber)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newUserResponse(user)))
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// changePasswordResponse carries a new access token, the one the request was made with was issued before
+// the password changed so authMiddleware no longer accepts it
+type changePasswordResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	RevokedSessions      int64     `json:"revoked_sessions"`
+}
+
+// changePassword sets a new password for the authenticated user, every other session is logged out
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, valid := server.checkCurrentPassword(ctx, authPayload.UserID, req.CurrentPassword)
+	if !valid {
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ChangePasswordTx(ctx, db.ChangePasswordTxParams{
+		Email:          user.Email,
+		HashedPassword: hashedPassword,
+		SessionID:      authPayload.SessionID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.sessions.forgetUser(user.Email)
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.ID, user.AccountName, user.Email, authPayload.SessionID, server.config.AccessTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(changePasswordResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		RevokedSessions:      result.RevokedSessions,
+	}))
+}
+
+type changeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type changeEmailResponse struct {
+	User            createUserResponse `json:"user"`
+	RevokedSessions int64              `json:"revoked_sessions"`
+}
+
+// changeEmail moves the authenticated user to a new email, which has to be verified again. Every session is
+// logged out, including this one, since tokens carry the old email
+func (server *Server) changeEmail(ctx *gin.Context) {
+	var req changeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, valid := server.checkCurrentPassword(ctx, authPayload.UserID, req.Password)
+	if !valid {
+		return
+	}
+
+	if req.Email == user.Email {
+		err := errors.New("email is the same as the current one")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ChangeEmailTx(ctx, db.ChangeEmailTxParams{
+		UserID: user.ID,
+		Email:  req.Email,
+		AfterUpdate: func(user db.User) error {
+			taskPayload := &worker.PayloadSendVerifyEmail{Email: user.Email}
+
+			opts := []asynq.Option{
+				asynq.MaxRetry(10),
+				asynq.ProcessIn(10 * time.Second),
+				asynq.Queue(worker.QueueCritical),
+			}
+			return server.taskDistributor.DistributeTaskSendVerifyEmail(ctx, taskPayload, opts...)
+		},
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			err = fmt.Errorf("email %s is already in use", req.Email)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.sessions.forgetUser(user.Email)
+
+	ctx.JSON(http.StatusOK, validResponse(changeEmailResponse{
+		User:            newUserResponse(result.User),
+		RevokedSessions: result.RevokedSessions,
+	}))
+}
+
+// checkCurrentPassword gets the user and checks the password they sent is theirs, it writes the error response when it can't
+func (server *Server) checkCurrentPassword(ctx *gin.Context, userID int64, password string) (db.User, bool) {
+	user, err := server.store.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("user with id %v doesn't exist", userID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return user, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, false
+	}
+
+	if err := util.ComparePasswords(password, user.HashedPassword); err != nil {
+		err = errors.New("current password is incorrect")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return user, false
+	}
+
+	return user, true
+}
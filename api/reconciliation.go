@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+type listReconciliationRunsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listReconciliationRuns lists the ledger reconciliation runs, the latest first
+func (server *Server) listReconciliationRuns(ctx *gin.Context) {
+	var req listReconciliationRunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	runs, err := server.store.ListReconciliationRuns(ctx, db.ListReconciliationRunsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(runs))
+}
+
+type getReconciliationRunRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// reconciliationDiscrepancyResponse renders expected and actual in the currency of the discrepancy's account
+type reconciliationDiscrepancyResponse struct {
+	db.ReconciliationDiscrepancy
+	CurrencyCode string `json:"currency_code"`
+	Expected     string `json:"expected"`
+	Actual       string `json:"actual"`
+}
+
+type reconciliationRunResponse struct {
+	Run           db.ReconciliationRun                `json:"run"`
+	Discrepancies []reconciliationDiscrepancyResponse `json:"discrepancies"`
+}
+
+// getReconciliationRun returns a reconciliation run with every discrepancy it found
+func (server *Server) getReconciliationRun(ctx *gin.Context) {
+	var req getReconciliationRunRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	run, err := server.store.GetReconciliationRun(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("reconciliation run with id %v doesnt exist", req.ID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	discrepancies, err := server.store.ListReconciliationDiscrepancies(ctx, run.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	accountIDs := make([]int64, 0, len(discrepancies))
+	for _, discrepancy := range discrepancies {
+		accountIDs = append(accountIDs, discrepancy.AccountID)
+	}
+
+	currencyCodes, err := server.accountCurrencyCodes(ctx, accountIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := reconciliationRunResponse{
+		Run:           run,
+		Discrepancies: make([]reconciliationDiscrepancyResponse, 0, len(discrepancies)),
+	}
+	for _, discrepancy := range discrepancies {
+		currencyCode := currencyCodes[discrepancy.AccountID]
+		rsp.Discrepancies = append(rsp.Discrepancies, reconciliationDiscrepancyResponse{
+			ReconciliationDiscrepancy: discrepancy,
+			CurrencyCode:              currencyCode,
+			Expected:                  formatAmount(discrepancy.Expected, currencyCode),
+			Actual:                    formatAmount(discrepancy.Actual, currencyCode),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	mockwk "github.com/kelvinator07/golang-bank-microservices/worker/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetReconciliationRunAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.ID = 1
+	admin.Role = db.UserRoleAdmin
+
+	run := db.ReconciliationRun{
+		ID:               3,
+		TriggeredBy:      db.ReconciliationTriggeredBySchedule,
+		AccountsChecked:  10,
+		TransfersChecked: 20,
+		Discrepancies:    1,
+	}
+
+	discrepancies := []db.ReconciliationDiscrepancy{
+		{
+			ID:         1,
+			RunID:      run.ID,
+			Kind:       db.DiscrepancyKindTransferCredit,
+			AccountID:  4,
+			TransferID: pgtype.Int8{Int64: 5, Valid: true},
+			Expected:   100,
+			Actual:     90,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().ListReconciliationDiscrepancies(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(discrepancies, nil)
+				store.EXPECT().
+					ListAccountsByIDs(gomock.Any(), gomock.Eq([]int64{4})).
+					Times(1).
+					Return([]db.Account{{ID: 4, CurrencyCode: util.NGN}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var res struct {
+					Data reconciliationRunResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				assert.NoError(t, err)
+				assert.Equal(t, run.ID, res.Data.Run.ID)
+				assert.Len(t, res.Data.Discrepancies, 1)
+				assert.Equal(t, discrepancies[0].ID, res.Data.Discrepancies[0].ID)
+				assert.Equal(t, util.NGN, res.Data.Discrepancies[0].CurrencyCode)
+				assert.Equal(t, "1.00", res.Data.Discrepancies[0].Expected)
+				assert.Equal(t, "0.90", res.Data.Discrepancies[0].Actual)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(db.ReconciliationRun{}, db.ErrRecordNotFound)
+				store.EXPECT().ListReconciliationDiscrepancies(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.ID)).Times(1).Return(admin, nil)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/admin/reconciliation-runs/%d", run.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.ID, admin.AccountName, admin.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListReconciliationRunsAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.ID = 1
+	admin.Role = db.UserRoleAdmin
+
+	runs := []db.ReconciliationRun{
+		{ID: 2, TriggeredBy: db.ReconciliationTriggeredByCLI},
+		{ID: 1, TriggeredBy: db.ReconciliationTriggeredBySchedule},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.ID)).Times(1).Return(admin, nil)
+	store.EXPECT().
+		ListReconciliationRuns(gomock.Any(), gomock.Eq(db.ListReconciliationRunsParams{Limit: 5, Offset: 0})).
+		Times(1).
+		Return(runs, nil)
+
+	server := newTestServer(t, store, mockwk.NewMockTaskDistributor(ctrl))
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/admin/reconciliation-runs?page_id=1&page_size=5", nil)
+	assert.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.ID, admin.AccountName, admin.Email, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var res struct {
+		Data []db.ReconciliationRun `json:"data"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, runs, res.Data)
+}
@@ -0,0 +1,359 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/token"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	"github.com/kelvinator07/golang-bank-microservices/worker"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64      `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64      `json:"to_account_id" binding:"required,min=1,nefield=FromAccountID"`
+	Amount        string     `json:"amount" binding:"required,amount"`
+	CurrencyCode  string     `json:"currency_code" binding:"required,currencyCode"`
+	Schedule      string     `json:"schedule"`
+	StartAt       *time.Time `json:"start_at"`
+}
+
+type scheduledTransferResponse struct {
+	db.ScheduledTransfer
+	Amount string `json:"amount"`
+}
+
+func newScheduledTransferResponse(scheduledTransfer db.ScheduledTransfer) scheduledTransferResponse {
+	return scheduledTransferResponse{
+		ScheduledTransfer: scheduledTransfer,
+		Amount:            formatAmount(scheduledTransfer.Amount, scheduledTransfer.CurrencyCode),
+	}
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	amount, valid := parseAmount(ctx, req.Amount, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	if err := util.ValidateSchedule(req.Schedule); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// a one-off transfer runs at start_at, a recurring one at start_at or else the first time its schedule fires
+	var nextRunAt time.Time
+	if req.StartAt != nil {
+		if !req.StartAt.After(time.Now()) {
+			err := errors.New("start_at must be in the future")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		nextRunAt = *req.StartAt
+	} else {
+		next, ok, err := util.NextScheduleRun(req.Schedule, time.Now())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if !ok {
+			err := errors.New("start_at is required for a one-off transfer")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		nextRunAt = next
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !fromAccount.OwnedBy(authPayload.UserID) {
+		err := errors.New("from account doesnt belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// cross currency scheduled transfers would lock in whatever rate applies on the day, so they aren't offered
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	arg := db.CreateScheduledTransferTxParams{
+		CreateScheduledTransferParams: db.CreateScheduledTransferParams{
+			UserID:        authPayload.UserID,
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        amount,
+			CurrencyCode:  req.CurrencyCode,
+			Schedule:      req.Schedule,
+			NextRunAt:     nextRunAt,
+		},
+		AfterCreate: func(scheduledTransfer db.ScheduledTransfer) error {
+			return server.distributeScheduledTransfer(ctx, scheduledTransfer)
+		},
+	}
+
+	result, err := server.store.CreateScheduledTransferTx(ctx, arg)
+	if err != nil {
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newScheduledTransferResponse(result.ScheduledTransfer)))
+}
+
+type getScheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduledTransfer, valid := server.ownedScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newScheduledTransferResponse(scheduledTransfer)))
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduledTransfers, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		UserID: authPayload.UserID,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]scheduledTransferResponse, 0, len(scheduledTransfers))
+	for _, scheduledTransfer := range scheduledTransfers {
+		rsp = append(rsp, newScheduledTransferResponse(scheduledTransfer))
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+type updateScheduledTransferRequest struct {
+	Amount *string `json:"amount" binding:"omitempty,amount"`
+	Status *string `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+// updateScheduledTransfer changes the amount of future runs, or pauses and resumes the schedule
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduledTransfer, valid := server.ownedScheduledTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	if !scheduledTransferEditable(ctx, scheduledTransfer) {
+		return
+	}
+
+	arg := db.UpdateScheduledTransferParams{
+		ID: scheduledTransfer.ID,
+	}
+	if req.Amount != nil {
+		amount, valid := parseAmount(ctx, *req.Amount, scheduledTransfer.CurrencyCode)
+		if !valid {
+			return
+		}
+		arg.Amount = pgtype.Int8{Int64: amount, Valid: true}
+	}
+	if req.Status != nil {
+		arg.Status = pgtype.Text{String: *req.Status, Valid: true}
+	}
+
+	// runs missed while paused are skipped, the schedule resumes from now
+	resume := req.Status != nil && *req.Status == db.ScheduledTransferStatusActive
+	if resume && scheduledTransfer.NextRunAt.Before(time.Now()) {
+		next, ok, err := util.NextScheduleRun(scheduledTransfer.Schedule, time.Now())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if !ok {
+			next = time.Now()
+		}
+		arg.NextRunAt = pgtype.Timestamptz{Time: next, Valid: true}
+	}
+
+	scheduledTransfer, err := server.store.UpdateScheduledTransfer(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// queueing a run that is already queued is a no-op, so resuming an active transfer is safe to retry
+	if resume {
+		if err := server.distributeScheduledTransfer(ctx, scheduledTransfer); err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newScheduledTransferResponse(scheduledTransfer)))
+}
+
+// deleteScheduledTransfer cancels the schedule, the row and its runs are kept for history
+func (server *Server) deleteScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduledTransfer, valid := server.ownedScheduledTransfer(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	if !scheduledTransferEditable(ctx, scheduledTransfer) {
+		return
+	}
+
+	scheduledTransfer, err := server.store.UpdateScheduledTransfer(ctx, db.UpdateScheduledTransferParams{
+		ID:     scheduledTransfer.ID,
+		Status: pgtype.Text{String: db.ScheduledTransferStatusCancelled, Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newScheduledTransferResponse(scheduledTransfer)))
+}
+
+type listScheduledTransferRunsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listScheduledTransferRunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduledTransfer, valid := server.ownedScheduledTransfer(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	runs, err := server.store.ListScheduledTransferRuns(ctx, db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		Limit:               req.PageSize,
+		Offset:              (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(runs))
+}
+
+// ownedScheduledTransfer fetches a scheduled transfer and checks it belongs to the authenticated user
+func (server *Server) ownedScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	scheduledTransfer, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("scheduled transfer with id %v doesnt exist", id)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return scheduledTransfer, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return scheduledTransfer, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduledTransfer.UserID != authPayload.UserID {
+		err := errors.New("scheduled transfer doesnt belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return scheduledTransfer, false
+	}
+
+	return scheduledTransfer, true
+}
+
+// scheduledTransferEditable rejects changes to transfers that have finished or were cancelled
+func scheduledTransferEditable(ctx *gin.Context, scheduledTransfer db.ScheduledTransfer) bool {
+	switch scheduledTransfer.Status {
+	case db.ScheduledTransferStatusActive, db.ScheduledTransferStatusPaused:
+		return true
+	}
+
+	err := fmt.Errorf("scheduled transfer [%d] is %s", scheduledTransfer.ID, scheduledTransfer.Status)
+	ctx.JSON(http.StatusBadRequest, errorResponse(err))
+	return false
+}
+
+func (server *Server) distributeScheduledTransfer(ctx *gin.Context, scheduledTransfer db.ScheduledTransfer) error {
+	taskPayload := &worker.PayloadExecuteScheduledTransfer{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               scheduledTransfer.NextRunAt,
+	}
+
+	err := server.taskDistributor.DistributeTaskExecuteScheduledTransfer(ctx, taskPayload, worker.ScheduledTransferTaskOptions(scheduledTransfer)...)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+	return nil
+}
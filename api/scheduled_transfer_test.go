@@ -0,0 +1,466 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	"github.com/kelvinator07/golang-bank-microservices/worker"
+	mockwk "github.com/kelvinator07/golang-bank-microservices/worker/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user1.ID = 1
+	user2, _ := randomUser(t)
+	user2.ID = 2
+
+	account1 := randomActiveAccount(user1.ID, 1, util.USD)
+	account2 := randomActiveAccount(user2.ID, 2, util.USD)
+	account3 := randomActiveAccount(user2.ID, 3, util.NGN)
+
+	amount := int64(10)
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OneOff",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "0.10",
+				"currency_code":   util.USD,
+				"start_at":        startAt,
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				scheduledTransfer := db.ScheduledTransfer{
+					ID:            1,
+					UserID:        user1.ID,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					CurrencyCode:  util.USD,
+					Status:        db.ScheduledTransferStatusActive,
+					NextRunAt:     startAt,
+				}
+
+				store.EXPECT().
+					CreateScheduledTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateScheduledTransferTxParams) (db.CreateScheduledTransferTxResult, error) {
+						assert.Equal(t, user1.ID, arg.UserID)
+						assert.Equal(t, amount, arg.Amount)
+						assert.Empty(t, arg.Schedule)
+						assert.True(t, startAt.Equal(arg.NextRunAt))
+
+						err := arg.AfterCreate(scheduledTransfer)
+						return db.CreateScheduledTransferTxResult{ScheduledTransfer: scheduledTransfer}, err
+					})
+
+				payload := &worker.PayloadExecuteScheduledTransfer{
+					ScheduledTransferID: scheduledTransfer.ID,
+					RunAt:               startAt,
+				}
+				distributor.EXPECT().
+					DistributeTaskExecuteScheduledTransfer(gomock.Any(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Recurring",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "0.10",
+				"currency_code":   util.USD,
+				"schedule":        "0 9 1 * *",
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				store.EXPECT().
+					CreateScheduledTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateScheduledTransferTxParams) (db.CreateScheduledTransferTxResult, error) {
+						assert.Equal(t, "0 9 1 * *", arg.Schedule)
+						assert.Equal(t, 1, arg.NextRunAt.Day())
+						assert.Equal(t, 9, arg.NextRunAt.Hour())
+						return db.CreateScheduledTransferTxResult{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidSchedule",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "0.10",
+				"currency_code":   util.USD,
+				"schedule":        "every payday",
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "OneOffWithoutStartAt",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "0.10",
+				"currency_code":   util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "StartAtInPast",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "0.10",
+				"currency_code":   util.USD,
+				"start_at":        time.Now().Add(-time.Hour),
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedFromAccount",
+			body: gin.H{
+				"from_account_id": account2.ID,
+				"to_account_id":   account1.ID,
+				"amount":          "0.10",
+				"currency_code":   util.USD,
+				"start_at":        startAt,
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ToCurrencyMismatch",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          "0.10",
+				"currency_code":   util.USD,
+				"start_at":        startAt,
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			distributor := mockwk.NewMockTaskDistributor(workerCtrl)
+			tc.buildStubs(store, distributor)
+
+			server := newTestServer(t, store, distributor)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/scheduled-transfers", bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.ID, user1.AccountName, user1.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = 1
+	otherUser, _ := randomUser(t)
+	otherUser.ID = 2
+
+	scheduledTransfer := db.ScheduledTransfer{
+		ID:            util.RandomInt(1, 1000),
+		UserID:        user.ID,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        10,
+		CurrencyCode:  util.USD,
+		Schedule:      "@daily",
+		Status:        db.ScheduledTransferStatusActive,
+		NextRunAt:     time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		body          gin.H
+		current       func() db.ScheduledTransfer
+		buildStubs    func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Pause",
+			method: http.MethodPatch,
+			body:   gin.H{"status": db.ScheduledTransferStatusPaused},
+			current: func() db.ScheduledTransfer {
+				return scheduledTransfer
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.UpdateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						assert.Equal(t, db.ScheduledTransferStatusPaused, arg.Status.String)
+						assert.False(t, arg.Amount.Valid)
+						assert.False(t, arg.NextRunAt.Valid)
+						return scheduledTransfer, nil
+					})
+				distributor.EXPECT().DistributeTaskExecuteScheduledTransfer(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "ResumeAfterMissedRun",
+			method: http.MethodPatch,
+			body:   gin.H{"status": db.ScheduledTransferStatusActive},
+			current: func() db.ScheduledTransfer {
+				paused := scheduledTransfer
+				paused.Status = db.ScheduledTransferStatusPaused
+				paused.NextRunAt = time.Now().Add(-time.Hour)
+				return paused
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.UpdateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						assert.Equal(t, db.ScheduledTransferStatusActive, arg.Status.String)
+						assert.True(t, arg.NextRunAt.Valid)
+						assert.True(t, arg.NextRunAt.Time.After(time.Now()))
+						return scheduledTransfer, nil
+					})
+
+				payload := &worker.PayloadExecuteScheduledTransfer{
+					ScheduledTransferID: scheduledTransfer.ID,
+					RunAt:               scheduledTransfer.NextRunAt,
+				}
+				// the run may already be queued from before the pause
+				distributor.EXPECT().
+					DistributeTaskExecuteScheduledTransfer(gomock.Any(), gomock.Eq(payload), gomock.Any()).
+					Times(1).
+					Return(fmt.Errorf("failed to enqueue task %w", asynq.ErrTaskIDConflict))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "UpdateAmount",
+			method: http.MethodPatch,
+			body:   gin.H{"amount": "0.50"},
+			current: func() db.ScheduledTransfer {
+				return scheduledTransfer
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				arg := db.UpdateScheduledTransferParams{
+					ID: scheduledTransfer.ID,
+				}
+				arg.Amount.Int64, arg.Amount.Valid = 50, true
+
+				updated := scheduledTransfer
+				updated.Amount = 50
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				updated := scheduledTransfer
+				updated.Amount = 50
+				requireBodyMatchData(t, recorder.Body.Bytes(), newScheduledTransferResponse(updated))
+			},
+		},
+		{
+			name:   "InvalidStatus",
+			method: http.MethodPatch,
+			body:   gin.H{"status": db.ScheduledTransferStatusCompleted},
+			current: func() db.ScheduledTransfer {
+				return scheduledTransfer
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "Cancel",
+			method: http.MethodDelete,
+			current: func() db.ScheduledTransfer {
+				return scheduledTransfer
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				arg := db.UpdateScheduledTransferParams{
+					ID: scheduledTransfer.ID,
+				}
+				arg.Status.String, arg.Status.Valid = db.ScheduledTransferStatusCancelled, true
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).Return(scheduledTransfer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "CancelCompleted",
+			method: http.MethodDelete,
+			current: func() db.ScheduledTransfer {
+				completed := scheduledTransfer
+				completed.Status = db.ScheduledTransferStatusCompleted
+				return completed
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "UnauthorizedUser",
+			method: http.MethodDelete,
+			current: func() db.ScheduledTransfer {
+				other := scheduledTransfer
+				other.UserID = otherUser.ID
+				return other
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			method: http.MethodDelete,
+			current: func() db.ScheduledTransfer {
+				return db.ScheduledTransfer{}
+			},
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			current := tc.current()
+			var getErr error
+			if current.ID == 0 {
+				getErr = db.ErrRecordNotFound
+			}
+			store.EXPECT().
+				GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).
+				AnyTimes().
+				Return(current, getErr)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			distributor := mockwk.NewMockTaskDistributor(workerCtrl)
+			tc.buildStubs(store, distributor)
+
+			server := newTestServer(t, store, distributor)
+			recorder := httptest.NewRecorder()
+
+			var body *bytes.Reader
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				assert.NoError(t, err)
+				body = bytes.NewReader(data)
+			} else {
+				body = bytes.NewReader(nil)
+			}
+
+			url := fmt.Sprintf("/api/v1/scheduled-transfers/%d", scheduledTransfer.ID)
+			request, err := http.NewRequest(tc.method, url, body)
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
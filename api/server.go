@@ -6,7 +6,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/kelvinator07/golang-bank-microservices/accountnumber"
 	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/fx"
 	"github.com/kelvinator07/golang-bank-microservices/token"
 	"github.com/kelvinator07/golang-bank-microservices/util"
 	"github.com/kelvinator07/golang-bank-microservices/worker"
@@ -25,6 +27,9 @@ type Server struct {
 	tokenMaker      token.Maker
 	router          *gin.Engine
 	taskDistributor worker.TaskDistributor
+	rateProvider    fx.RateProvider
+	accountNumbers  accountnumber.Generator
+	sessions        *sessionCache
 }
 
 func NewServer(config util.Env, store db.Store, taskDistributor worker.TaskDistributor) (*Server, error) {
@@ -33,15 +38,30 @@ func NewServer(config util.Env, store db.Store, taskDistributor worker.TaskDistr
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	rateProvider, err := newRateProvider(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create rate provider: %w", err)
+	}
+
+	accountNumbers, err := accountnumber.NewNUBANGenerator(config.BankCode)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create account number generator: %w", err)
+	}
+
 	server := &Server{
 		config:          config,
 		store:           store,
 		tokenMaker:      tokenMaker,
 		taskDistributor: taskDistributor,
+		rateProvider:    rateProvider,
+		accountNumbers:  accountNumbers,
+		sessions:        newSessionCache(store, config.SessionCacheTTL),
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currencyCode", validCurrency)
+		v.RegisterValidation("amount", validAmount)
+		v.RegisterValidation("decimal", validDecimal)
 	}
 
 	// Add logging middleware
@@ -59,21 +79,84 @@ func (server *Server) setupRouter() {
 	router.POST("/api/v1/users/login", server.loginUser)
 	router.POST("/api/v1/tokens/renew_access", server.renewAccessToken)
 	router.GET("/api/v1/verify-email", server.verifyEmail)
+	router.POST("/api/v1/users/password/forgot", server.forgotPassword)
+	router.POST("/api/v1/users/password/reset", server.resetPassword)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.sessions))
 	authRoutes.GET("/api/v1/users/:id", server.getOneUser)
 	authRoutes.GET("/api/v1/users", server.getAllUsers2)
+	authRoutes.POST("/api/v1/users/logout", server.logoutUser)
+	authRoutes.PATCH("/api/v1/users/me", server.updateProfile)
+	authRoutes.POST("/api/v1/users/me/password", server.changePassword)
+	authRoutes.POST("/api/v1/users/me/email", server.changeEmail)
+	authRoutes.GET("/api/v1/sessions", server.listSessions)
+	authRoutes.DELETE("/api/v1/sessions", server.revokeAllSessions)
+	authRoutes.DELETE("/api/v1/sessions/:id", server.revokeSession)
 	// authRoutes.GET("/api/v1/users", server.getAllUsers2)
 
 	authRoutes.POST("/api/v1/accounts", server.createAccount)
 	authRoutes.GET("/api/v1/accounts/:id", server.getAccount)
+	authRoutes.GET("/api/v1/accounts/by-number/:number", server.getAccountByNumber)
 	authRoutes.GET("/api/v1/accounts", server.getAllAccounts)
-
-	authRoutes.POST("/api/v1/transfers", server.createTransfer)
+	authRoutes.POST("/api/v1/accounts/:id/activate", server.activateAccount)
+	authRoutes.POST("/api/v1/accounts/:id/close", server.closeAccount)
+	authRoutes.GET("/api/v1/accounts/:id/status-history", server.listAccountStatusHistory)
+	authRoutes.GET("/api/v1/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/api/v1/accounts/:id/transfers", server.listAccountTransfers)
+	authRoutes.GET("/api/v1/accounts/:id/holds", server.listAccountHolds)
+	authRoutes.GET("/api/v1/accounts/:id/balance", server.getAccountBalance)
+	authRoutes.GET("/api/v1/accounts/:id/statement", server.getAccountStatement)
+
+	authRoutes.POST("/api/v1/transfers", idempotencyMiddleware(server.store, server.config.IdempotencyKeyTTL), server.createTransfer)
+	authRoutes.POST("/api/v1/transfers/:id/reverse", idempotencyMiddleware(server.store, server.config.IdempotencyKeyTTL), server.reverseTransfer)
+	authRoutes.GET("/api/v1/transfer-limits", server.getTransferLimits)
+
+	authRoutes.POST("/api/v1/holds", idempotencyMiddleware(server.store, server.config.IdempotencyKeyTTL), server.placeHold)
+	authRoutes.GET("/api/v1/holds/:id", server.getHold)
+	authRoutes.POST("/api/v1/holds/:id/capture", idempotencyMiddleware(server.store, server.config.IdempotencyKeyTTL), server.captureHold)
+	authRoutes.POST("/api/v1/holds/:id/void", server.voidHold)
+
+	authRoutes.POST("/api/v1/scheduled-transfers", server.createScheduledTransfer)
+	authRoutes.GET("/api/v1/scheduled-transfers", server.listScheduledTransfers)
+	authRoutes.GET("/api/v1/scheduled-transfers/:id", server.getScheduledTransfer)
+	authRoutes.PATCH("/api/v1/scheduled-transfers/:id", server.updateScheduledTransfer)
+	authRoutes.DELETE("/api/v1/scheduled-transfers/:id", server.deleteScheduledTransfer)
+	authRoutes.GET("/api/v1/scheduled-transfers/:id/runs", server.listScheduledTransferRuns)
+
+	authRoutes.POST("/api/v1/transfer-batches", server.createTransferBatch)
+	authRoutes.GET("/api/v1/transfer-batches", server.listTransferBatches)
+	authRoutes.GET("/api/v1/transfer-batches/:id", server.getTransferBatch)
+	authRoutes.GET("/api/v1/transfer-batches/:id/items", server.listTransferBatchItems)
+
+	adminRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.sessions), adminMiddleware(server.store))
+	adminRoutes.PATCH("/api/v1/admin/accounts/:id/overdraft-limit", server.setOverdraftLimit)
+	adminRoutes.POST("/api/v1/admin/accounts/:id/freeze", server.freezeAccount)
+	adminRoutes.POST("/api/v1/admin/accounts/:id/unfreeze", server.unfreezeAccount)
+	adminRoutes.PATCH("/api/v1/admin/users/:id/tier", server.setUserTier)
+	adminRoutes.PUT("/api/v1/admin/transfer-limits/:tier/:currency_code", server.setTransferLimit)
+	adminRoutes.GET("/api/v1/admin/fee-rules", server.listFeeRules)
+	adminRoutes.POST("/api/v1/admin/fee-rules", server.createFeeRule)
+	adminRoutes.DELETE("/api/v1/admin/fee-rules/:id", server.deleteFeeRule)
+	adminRoutes.POST("/api/v1/admin/deposits", idempotencyMiddleware(server.store, server.config.IdempotencyKeyTTL), server.deposit)
+	adminRoutes.POST("/api/v1/admin/withdrawals", idempotencyMiddleware(server.store, server.config.IdempotencyKeyTTL), server.withdraw)
+	adminRoutes.GET("/api/v1/admin/trial-balance", server.getTrialBalance)
+	adminRoutes.GET("/api/v1/admin/reconciliation-runs", server.listReconciliationRuns)
+	adminRoutes.GET("/api/v1/admin/reconciliation-runs/:id", server.getReconciliationRun)
+	adminRoutes.GET("/api/v1/admin/currencies", server.listCurrencies)
+	adminRoutes.POST("/api/v1/admin/currencies", server.createCurrency)
+	adminRoutes.PATCH("/api/v1/admin/currencies/:code", server.updateCurrency)
 
 	server.router = router
 }
 
+// newRateProvider loads FX rates from FX_RATES_FILE, without one only same currency transfers are possible
+func newRateProvider(config util.Env) (fx.RateProvider, error) {
+	if config.FXRatesFile == "" {
+		return fx.NewStaticRateProvider(nil)
+	}
+	return fx.NewFileRateProvider(config.FXRatesFile)
+}
+
 func (server *Server) Start(serverAddress string) error {
 	return server.router.Run(serverAddress)
 }
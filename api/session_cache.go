@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+const defaultSessionCacheTTL = 30 * time.Second
+
+// sessionCache keeps what authMiddleware needs to know about a session for a short while, so checking access tokens
+// doesn't query the database on every request. A session revoked through another instance keeps working here until
+// its entry expires, revocations made through this server are forgotten straight away
+type sessionCache struct {
+	store db.Store
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	entries   map[uuid.UUID]sessionCacheEntry
+	lastSweep time.Time
+}
+
+type sessionCacheEntry struct {
+	session   db.GetSessionAuthRow
+	fetchedAt time.Time
+}
+
+func newSessionCache(store db.Store, ttl time.Duration) *sessionCache {
+	if ttl <= 0 {
+		ttl = defaultSessionCacheTTL
+	}
+
+	return &sessionCache{
+		store:     store,
+		ttl:       ttl,
+		entries:   make(map[uuid.UUID]sessionCacheEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// get returns the session from the cache, or from the store when it isn't cached or its entry has expired
+func (cache *sessionCache) get(ctx context.Context, sessionID uuid.UUID) (db.GetSessionAuthRow, error) {
+	now := time.Now()
+
+	cache.mu.Lock()
+	entry, ok := cache.entries[sessionID]
+	cache.mu.Unlock()
+
+	if ok && now.Sub(entry.fetchedAt) < cache.ttl {
+		return entry.session, nil
+	}
+
+	session, err := cache.store.GetSessionAuth(ctx, sessionID)
+	if err != nil {
+		return session, err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.entries[sessionID] = sessionCacheEntry{session: session, fetchedAt: now}
+
+	// drop the entries of sessions that stopped being used, at most once per ttl
+	if now.Sub(cache.lastSweep) >= cache.ttl {
+		for id, entry := range cache.entries {
+			if now.Sub(entry.fetchedAt) >= cache.ttl {
+				delete(cache.entries, id)
+			}
+		}
+		cache.lastSweep = now
+	}
+
+	return session, nil
+}
+
+// forgetUser drops the cached sessions of a user, after their sessions are blocked or their password changes
+func (cache *sessionCache) forgetUser(email string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for id, entry := range cache.entries {
+		if entry.session.Email == email {
+			delete(cache.entries, id)
+		}
+	}
+}
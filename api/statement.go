@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kelvinator07/golang-bank-microservices/statement"
+)
+
+const (
+	statementFormatCSV     = "csv"
+	statementFormatJSON    = "json"
+	statementFormatPDF     = "pdf"
+	statementFormatCamt053 = "camt053"
+	statementFormatMT940   = "mt940"
+)
+
+// maxStatementPeriod keeps a statement to about a year of entries
+const maxStatementPeriod = 366 * 24 * time.Hour
+
+// GET /api/v1/accounts/:id/statement?from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z&format=pdf
+// format is one of csv, json, pdf, camt053 and mt940, json by default
+type accountStatementRequest struct {
+	From   time.Time `form:"from" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	Format string    `form:"format" binding:"omitempty,oneof=csv json pdf camt053 mt940"`
+}
+
+// getAccountStatement returns the statement of an account for the entries created from up to, but not including, to.
+// JSON is wrapped like every other response, the other formats are sent as file downloads.
+// camt053 is ISO 20022 camt.053 XML and mt940 is a SWIFT MT940 message, for importing into accounting systems
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req accountStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.From.Before(req.To) {
+		err := errors.New("from must be before to")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.From.After(time.Now()) {
+		err := errors.New("from must not be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.To.Sub(req.From) > maxStatementPeriod {
+		err := fmt.Errorf("a statement can cover at most %v days", maxStatementPeriod/(24*time.Hour))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Format == "" {
+		req.Format = statementFormatJSON
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	stmt, err := statement.Generate(ctx, server.store, account, req.From, req.To)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var buf bytes.Buffer
+	var contentType string
+	extension := req.Format
+
+	switch req.Format {
+	case statementFormatJSON:
+		ctx.JSON(http.StatusOK, validResponse(newStatementResponse(stmt)))
+		return
+	case statementFormatCSV:
+		err = stmt.WriteCSV(&buf)
+		contentType = "text/csv"
+	case statementFormatPDF:
+		err = stmt.WritePDF(&buf)
+		contentType = "application/pdf"
+	case statementFormatCamt053:
+		err = stmt.WriteCamt053(&buf, time.Now())
+		contentType = "application/xml"
+		extension = "xml"
+	case statementFormatMT940:
+		err = stmt.WriteMT940(&buf)
+		contentType = "text/plain"
+		extension = "sta"
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, stmt.Filename(extension)))
+	ctx.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+type statementLineResponse struct {
+	statement.Line
+	Amount  string `json:"amount"`
+	Balance string `json:"balance"`
+}
+
+type statementResponse struct {
+	statement.Statement
+	OpeningBalance string                  `json:"opening_balance"`
+	TotalDebits    string                  `json:"total_debits"`
+	TotalCredits   string                  `json:"total_credits"`
+	ClosingBalance string                  `json:"closing_balance"`
+	Lines          []statementLineResponse `json:"lines"`
+}
+
+func newStatementResponse(stmt statement.Statement) statementResponse {
+	rsp := statementResponse{
+		Statement:      stmt,
+		OpeningBalance: formatAmount(stmt.OpeningBalance, stmt.CurrencyCode),
+		TotalDebits:    formatAmount(stmt.TotalDebits, stmt.CurrencyCode),
+		TotalCredits:   formatAmount(stmt.TotalCredits, stmt.CurrencyCode),
+		ClosingBalance: formatAmount(stmt.ClosingBalance, stmt.CurrencyCode),
+		Lines:          make([]statementLineResponse, 0, len(stmt.Lines)),
+	}
+	for _, line := range stmt.Lines {
+		rsp.Lines = append(rsp.Lines, statementLineResponse{
+			Line:    line,
+			Amount:  formatAmount(line.Amount, stmt.CurrencyCode),
+			Balance: formatAmount(line.Balance, stmt.CurrencyCode),
+		})
+	}
+	return rsp
+}
@@ -3,22 +3,31 @@ package api
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/token"
 )
 
 type renewAccessTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// renewAccessTokenResponse carries a new refresh token as well, the one that was sent is retired and must not be used again
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// renewAccessToken rotates the refresh token: the session is retired and replaced by a new one in the same family.
+// A retired refresh token being used again means it was copied, so the whole family is revoked
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -32,7 +41,7 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	sesssion, err := server.store.GetSession(ctx, refreshPayload.ID)
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -42,25 +51,30 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	if sesssion.IsBlocked {
-		err = fmt.Errorf("blocked session")
+	if session.Email != refreshPayload.Email {
+		err = fmt.Errorf("incorrect session user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	if sesssion.Email != refreshPayload.Email {
-		err = fmt.Errorf("incorrect session user")
+	if session.RefreshToken != req.RefreshToken {
+		err = fmt.Errorf("mismatched session token")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	if sesssion.RefreshToken != req.RefreshToken {
-		err = fmt.Errorf("mismatched session token")
+	if session.ReplacedBy.Valid {
+		server.revokeSessionFamily(ctx, session)
+		return
+	}
+
+	if session.IsBlocked {
+		err = fmt.Errorf("blocked session")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	if time.Now().After(sesssion.ExpiresAt) {
+	if time.Now().After(session.ExpiresAt) {
 		err = fmt.Errorf("expired session")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
@@ -77,16 +91,209 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.ID, user.AccountName, refreshPayload.Email, server.config.AccessTokenDuration)
+	refreshToken, newRefreshPayload, err := server.tokenMaker.CreateToken(user.ID, user.AccountName, user.Email, uuid.Nil, server.config.RefreshTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.ID, user.AccountName, user.Email, newRefreshPayload.ID, server.config.AccessTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	result, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:           newRefreshPayload.ID,
+			Email:        user.Email,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		// another renewal with the same refresh token got there first
+		if errors.Is(err, db.ErrSessionRetired) {
+			server.revokeSessionFamily(ctx, session)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	arg := renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		SessionID:             result.Session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}
 
 	ctx.JSON(http.StatusOK, NewValidHttpResponse(arg))
 }
+
+// revokeSessionFamily blocks every session rotated from the same login as a session whose retired refresh token was
+// used again, there's no telling whether the client or someone who copied the token holds the newest one
+func (server *Server) revokeSessionFamily(ctx *gin.Context, session db.Session) {
+	revoked, err := server.store.BlockSessionFamily(ctx, session.FamilyID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.sessions.forgetUser(session.Email)
+
+	log.Printf("suspected refresh token theft: retired session %v of %s was used from %s (%s), revoked %d sessions of family %v",
+		session.ID, session.Email, ctx.ClientIP(), ctx.Request.UserAgent(), revoked, session.FamilyID)
+
+	err = errors.New("refresh token was already used, the session has been revoked")
+	ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+}
+
+// sessionResponse leaves out the refresh token, which only the client that logged in should have
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSessionResponse(session db.Session) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: session.CreatedAt,
+	}
+}
+
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// logoutUser blocks the session of the refresh token, so it can't be used to renew access tokens anymore
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	session, valid := server.ownedSession(ctx, refreshPayload.ID)
+	if !valid {
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		err = fmt.Errorf("mismatched session token")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	server.blockSession(ctx, session.ID)
+}
+
+// listSessions lists the sessions of the authenticated user that can still renew access tokens, newest first
+func (server *Server) listSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	sessions, err := server.store.ListActiveSessions(ctx, authPayload.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		rsp = append(rsp, newSessionResponse(session))
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+type revokeSessionRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// revokeSession blocks one of the authenticated user's sessions, e.g. one on a device they lost
+func (server *Server) revokeSession(ctx *gin.Context) {
+	var req revokeSessionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	session, valid := server.ownedSession(ctx, uuid.MustParse(req.ID))
+	if !valid {
+		return
+	}
+
+	server.blockSession(ctx, session.ID)
+}
+
+type revokeAllSessionsResponse struct {
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+// revokeAllSessions logs the authenticated user out everywhere by blocking all of their sessions,
+// including the one the request was made from
+func (server *Server) revokeAllSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	revoked, err := server.store.BlockUserSessions(ctx, authPayload.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.sessions.forgetUser(authPayload.Email)
+
+	ctx.JSON(http.StatusOK, validResponse(revokeAllSessionsResponse{RevokedSessions: revoked}))
+}
+
+// ownedSession gets a session and checks it belongs to the authenticated user, it writes the error response when it can't
+func (server *Server) ownedSession(ctx *gin.Context, sessionID uuid.UUID) (db.Session, bool) {
+	session, err := server.store.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("session with id %v doesnt exist", sessionID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return session, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return session, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if session.Email != authPayload.Email {
+		err := errors.New("session doesnt belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return session, false
+	}
+
+	return session, true
+}
+
+// blockSession blocks a session and responds with it, blocking one that is already blocked changes nothing
+func (server *Server) blockSession(ctx *gin.Context, sessionID uuid.UUID) {
+	session, err := server.store.BlockSession(ctx, sessionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.sessions.forgetUser(session.Email)
+
+	ctx.JSON(http.StatusOK, validResponse(newSessionResponse(session)))
+}
@@ -3,20 +3,80 @@ package api
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
 	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/fx"
 	"github.com/kelvinator07/golang-bank-microservices/token"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	"github.com/kelvinator07/golang-bank-microservices/worker"
 )
 
 type transferRequest struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1,nefield=FromAccountID"`
+	Amount        string `json:"amount" binding:"required,amount"`
 	CurrencyCode  string `json:"currency_code" binding:"required,currencyCode"`
 }
 
+type entryResponse struct {
+	db.Entry
+	Amount string `json:"amount"`
+}
+
+func newEntryResponse(entry db.Entry, currencyCode string) entryResponse {
+	return entryResponse{
+		Entry:  entry,
+		Amount: formatAmount(entry.Amount, currencyCode),
+	}
+}
+
+// transferResponse renders amount, reversed_amount and fee in the from account's currency and to_amount in the to account's
+type transferResponse struct {
+	db.Transfer
+	Amount         string `json:"amount"`
+	ToAmount       string `json:"to_amount"`
+	ReversedAmount string `json:"reversed_amount"`
+	Fee            string `json:"fee"`
+}
+
+func newTransferResponse(transfer db.Transfer, fromCurrencyCode string, toCurrencyCode string) transferResponse {
+	return transferResponse{
+		Transfer:       transfer,
+		Amount:         formatAmount(transfer.Amount, fromCurrencyCode),
+		ToAmount:       formatAmount(transfer.ToAmount, toCurrencyCode),
+		ReversedAmount: formatAmount(transfer.ReversedAmount, fromCurrencyCode),
+		Fee:            formatAmount(transfer.Fee, fromCurrencyCode),
+	}
+}
+
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+	Fee         string           `json:"fee"`
+	FeeEntry    entryResponse    `json:"fee_entry"`
+}
+
+func newTransferTxResponse(result db.TransferTxResult) transferTxResponse {
+	fromCurrencyCode, toCurrencyCode := result.FromAccount.CurrencyCode, result.ToAccount.CurrencyCode
+
+	return transferTxResponse{
+		Transfer:    newTransferResponse(result.Transfer, fromCurrencyCode, toCurrencyCode),
+		FromAccount: newAccountResponse(result.FromAccount),
+		ToAccount:   newAccountResponse(result.ToAccount),
+		FromEntry:   newEntryResponse(result.FromEntry, fromCurrencyCode),
+		ToEntry:     newEntryResponse(result.ToEntry, toCurrencyCode),
+		Fee:         formatAmount(result.Fee, fromCurrencyCode),
+		FeeEntry:    newEntryResponse(result.FeeEntry, fromCurrencyCode),
+	}
+}
+
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -24,44 +84,204 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	amount, valid := parseAmount(ctx, req.Amount, req.CurrencyCode)
+	if !valid {
+		return
+	}
+
 	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.CurrencyCode)
 	if !valid {
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if fromAccount.UserID != authPayload.UserID {
+	if !fromAccount.OwnedBy(authPayload.UserID) {
 		err := errors.New("from account doesnt belong to the authenticated user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	_, valid = server.validAccountBalance(ctx, req.FromAccountID, req.Amount)
+	_, valid = server.validAccountBalance(ctx, req.FromAccountID, amount)
 	if !valid {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.CurrencyCode)
+	toAccount, valid := server.activeAccount(ctx, req.ToAccountID)
 	if !valid {
 		return
 	}
 
+	// the request's currency_code only covers the from account, the money can't land in a disabled currency either
+	if !util.IsEnabledCurrency(toAccount.CurrencyCode) {
+		err := fmt.Errorf("%w: account [%d] is in %s", db.ErrCurrencyDisabled, toAccount.ID, toAccount.CurrencyCode)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		Amount:        amount,
+	}
+
+	if toAccount.CurrencyCode != fromAccount.CurrencyCode {
+		rate, err := server.rateProvider.GetRate(ctx, fromAccount.CurrencyCode, toAccount.CurrencyCode)
+		if err != nil {
+			if errors.Is(err, fx.ErrRateNotFound) {
+				ctx.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		arg.ExchangeRate = &rate
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(http.StatusBadRequest, transferLimitErrorResponse(limitErr))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrCurrencyMismatch) ||
+			errors.Is(err, db.ErrAmountTooSmall) || errors.Is(err, db.ErrAmountTooLarge) ||
+			errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrNotCustomerAccount) ||
+			errors.Is(err, db.ErrSameAccount) || errors.Is(err, db.ErrCurrencyDisabled) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, validResponse(result))
+	server.notifyOverdraft(ctx, result)
+
+	ctx.JSON(http.StatusOK, validResponse(newTransferTxResponse(result)))
+}
+
+type reverseTransferUriRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type reverseTransferRequest struct {
+	Amount string `json:"amount" binding:"omitempty,amount"`
+}
+
+// reverseTransferResponse renders the original transfer the other way round from the reversal,
+// its from account is the one the reversal credits
+type reverseTransferResponse struct {
+	OriginalTransfer transferResponse `json:"original_transfer"`
+	transferTxResponse
+}
+
+// reverseTransfer sends all or part of a transfer back to the account it came from.
+// Only the owner of the account that received the money can reverse it, since that account is debited
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri reverseTransferUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the amount is optional, an empty body reverses whatever is left
+	var req reverseTransferRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("transfer with id %v doesnt exist", uri.ID)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, valid := server.ownedAccount(ctx, transfer.ToAccountID)
+	if !valid {
+		return
+	}
+
+	// the amount is in the currency of the account the transfer came from
+	var amount int64
+	if req.Amount != "" {
+		fromAccount, err := server.store.GetAccount(ctx, transfer.FromAccountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		amount, valid = parseAmount(ctx, req.Amount, fromAccount.CurrencyCode)
+		if !valid {
+			return
+		}
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrTransferAlreadyReversed) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrTransferNotReversible) || errors.Is(err, db.ErrReversalAmountTooLarge) ||
+			errors.Is(err, db.ErrAmountTooSmall) || errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.notifyOverdraft(ctx, result.TransferTxResult)
+
+	ctx.JSON(http.StatusOK, validResponse(reverseTransferResponse{
+		OriginalTransfer: newTransferResponse(result.OriginalTransfer,
+			result.ToAccount.CurrencyCode, result.FromAccount.CurrencyCode),
+		transferTxResponse: newTransferTxResponse(result.TransferTxResult),
+	}))
+}
+
+// notifyOverdraft queues an email to the owner of an account the transfer took into overdraft.
+// The transfer has already committed, so failing to queue the notice doesn't fail the request
+func (server *Server) notifyOverdraft(ctx *gin.Context, result db.TransferTxResult) {
+	if !result.EnteredOverdraft() {
+		return
+	}
+
+	taskPayload := &worker.PayloadSendOverdraftNotice{AccountID: result.FromAccount.ID}
+	err := server.taskDistributor.DistributeTaskSendOverdraftNotice(ctx, taskPayload, asynq.Queue(worker.QueueDefault))
+	if err != nil {
+		log.Printf("failed to queue overdraft notice for account %d: %v", result.FromAccount.ID, err)
+	}
 }
 
 func (server Server) validAccount(ctx *gin.Context, accountID int64, currencyCode string) (db.Account, bool) {
+	account, valid := server.activeAccount(ctx, accountID)
+	if !valid {
+		return account, false
+	}
+
+	if account.CurrencyCode != currencyCode {
+		err := fmt.Errorf("account [%d] currency mistmatch: %s vs %s", account.ID, account.CurrencyCode, currencyCode)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// activeAccount fetches an account that can be debited or credited, in any currency
+func (server Server) activeAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
@@ -74,8 +294,15 @@ func (server Server) validAccount(ctx *gin.Context, accountID int64, currencyCod
 		return account, false
 	}
 
-	if account.CurrencyCode != currencyCode {
-		err := fmt.Errorf("account [%d] currency mistmatch: %s vs %s", account.ID, account.CurrencyCode, currencyCode)
+	if account.Status != db.AccountStatusActive {
+		err := fmt.Errorf("account [%d] is %s", account.ID, account.Status)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, false
+	}
+
+	// system accounts only move money through the admin ledger endpoints
+	if account.Kind != db.AccountKindCustomer {
+		err := fmt.Errorf("account [%d] is a %s account", account.ID, account.Kind)
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return account, false
 	}
@@ -91,8 +318,16 @@ func (server Server) validAccountBalance(ctx *gin.Context, accountID int64, amou
 		return account, false
 	}
 
-	if amount > account.Balance {
-		err := fmt.Errorf("account [%d] doesn't have enough balance: %v", account.ID, account.Balance)
+	// funds reserved by holds can't be spent twice, the overdraft limit can be
+	held, err := server.store.GetAccountHeldAmount(ctx, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	available := db.AvailableBalance(account, held)
+	if amount > available {
+		err := fmt.Errorf("account [%d] doesn't have enough available balance: %s", account.ID, util.NewMoney(available, account.CurrencyCode).Display())
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return account, false
 	}
@@ -0,0 +1,370 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kelvinator07/golang-bank-microservices/bulk"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/token"
+	"github.com/kelvinator07/golang-bank-microservices/worker"
+)
+
+// a full batch of bulk.MaxItems pain.001 transfers comes to well under a megabyte
+const maxTransferBatchFileSize = 2 << 20
+
+type createTransferBatchRequest struct {
+	FromAccountID int64                 `form:"from_account_id" binding:"required,min=1"`
+	Format        string                `form:"format" binding:"required,oneof=csv pain001"`
+	File          *multipart.FileHeader `form:"file" binding:"required"`
+}
+
+// createTransferBatch accepts a multipart upload of a csv or pain.001 file, every line is checked before
+// anything is stored and the transfers are then made one by one by the worker
+func (server *Server) createTransferBatch(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxTransferBatchFileSize)
+
+	var req createTransferBatchRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = fmt.Errorf("batch file is larger than %d bytes", maxTransferBatchFileSize)
+			ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.activeAccount(ctx, req.FromAccountID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !fromAccount.OwnedBy(authPayload.UserID) {
+		err := errors.New("from account doesnt belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	items, err := parseTransferBatch(req)
+	if err != nil {
+		var validationErr *bulk.ValidationError
+		if errors.As(err, &validationErr) {
+			ctx.JSON(http.StatusBadRequest, validationErrorResponse(validationErr))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	validationErr, err := server.validateTransferBatch(ctx, fromAccount, items)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if validationErr != nil {
+		ctx.JSON(http.StatusBadRequest, validationErrorResponse(validationErr))
+		return
+	}
+
+	arg := db.CreateTransferBatchTxParams{
+		CreateTransferBatchParams: db.CreateTransferBatchParams{
+			UserID:        authPayload.UserID,
+			FromAccountID: fromAccount.ID,
+			CurrencyCode:  fromAccount.CurrencyCode,
+			Format:        req.Format,
+			ItemCount:     int64(len(items)),
+		},
+		Items: make([]db.CreateTransferBatchItemParams, len(items)),
+	}
+	// validateTransferBatch has checked the total fits
+	for i, item := range items {
+		arg.TotalAmount += item.Amount
+		arg.Items[i] = db.CreateTransferBatchItemParams{
+			LineNumber:  int32(item.Line),
+			ToAccountID: item.ToAccountID,
+			Amount:      item.Amount,
+			Reference:   item.Reference,
+		}
+	}
+
+	result, err := server.store.CreateTransferBatchTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the batch is queued after it is committed, one that can't be queued is failed before any of it is made
+	taskPayload := &worker.PayloadProcessTransferBatch{BatchID: result.Batch.ID}
+	err = server.taskDistributor.DistributeTaskProcessTransferBatch(ctx, taskPayload, worker.TransferBatchTaskOptions(result.Batch)...)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		_, updateErr := server.store.UpdateTransferBatch(ctx, db.UpdateTransferBatchParams{
+			ID:          result.Batch.ID,
+			Status:      pgtype.Text{String: db.TransferBatchStatusFailed, Valid: true},
+			CompletedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		})
+		if updateErr != nil {
+			log.Printf("failed to mark transfer batch %d failed: %v", result.Batch.ID, updateErr)
+		}
+
+		err = fmt.Errorf("transfer batch %d couldn't be queued, none of its transfers were made: %w", result.Batch.ID, err)
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newTransferBatchResponse(result.Batch,
+		db.GetTransferBatchSummaryRow{PendingItems: result.Batch.ItemCount})))
+}
+
+func parseTransferBatch(req createTransferBatchRequest) ([]bulk.Item, error) {
+	file, err := req.File.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if req.Format == db.TransferBatchFormatPain001 {
+		return bulk.ParsePain001(file, req.FromAccountID)
+	}
+	return bulk.ParseCSV(file)
+}
+
+// validateTransferBatch checks every item against the accounts it moves money between and that the batch total
+// fits in an int64, the returned *bulk.ValidationError is nil when all of them can be made
+func (server *Server) validateTransferBatch(ctx *gin.Context, fromAccount db.Account, items []bulk.Item) (*bulk.ValidationError, error) {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ToAccountID)
+	}
+
+	accounts, err := server.store.ListAccountsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	toAccounts := make(map[int64]db.Account, len(accounts))
+	for _, account := range accounts {
+		toAccounts[account.ID] = account
+	}
+
+	validationErr := &bulk.ValidationError{}
+	var total int64
+	totalOverflowed := false
+	for _, item := range items {
+		toAccount, ok := toAccounts[item.ToAccountID]
+
+		// only the line that takes the total past what an int64 holds is reported
+		if !totalOverflowed {
+			if item.Amount > math.MaxInt64-total {
+				validationErr.Add(item.Line, "%v: batch total passes %s", db.ErrAmountTooLarge, formatAmount(math.MaxInt64, fromAccount.CurrencyCode))
+				totalOverflowed = true
+			} else {
+				total += item.Amount
+			}
+		}
+
+		switch {
+		case item.CurrencyCode != fromAccount.CurrencyCode:
+			validationErr.Add(item.Line, "currency %s doesnt match account [%d] currency %s", item.CurrencyCode, fromAccount.ID, fromAccount.CurrencyCode)
+		case item.ToAccountID == fromAccount.ID:
+			validationErr.Add(item.Line, "to account is the from account")
+		case !ok:
+			validationErr.Add(item.Line, "account with id %v doesnt exist", item.ToAccountID)
+		case toAccount.Status != db.AccountStatusActive:
+			validationErr.Add(item.Line, "account [%d] is %s", toAccount.ID, toAccount.Status)
+		case toAccount.Kind != db.AccountKindCustomer:
+			validationErr.Add(item.Line, "account [%d] is a %s account", toAccount.ID, toAccount.Kind)
+		case toAccount.CurrencyCode != fromAccount.CurrencyCode:
+			validationErr.Add(item.Line, "account [%d] currency mistmatch: %s vs %s", toAccount.ID, toAccount.CurrencyCode, fromAccount.CurrencyCode)
+		}
+	}
+
+	if len(validationErr.Lines) == 0 {
+		return nil, nil
+	}
+	return validationErr, nil
+}
+
+// validationErrorResponse is an errorResponse that also lists every line of the file that was rejected
+func validationErrorResponse(err *bulk.ValidationError) gin.H {
+	response := errorResponse(err)
+	response["errors"] = err.Lines
+	return response
+}
+
+type transferBatchSummaryResponse struct {
+	db.GetTransferBatchSummaryRow
+	CompletedAmount string `json:"completed_amount"`
+	FailedAmount    string `json:"failed_amount"`
+}
+
+type transferBatchRowResponse struct {
+	db.TransferBatch
+	TotalAmount string `json:"total_amount"`
+}
+
+func newTransferBatchRowResponse(batch db.TransferBatch) transferBatchRowResponse {
+	return transferBatchRowResponse{
+		TransferBatch: batch,
+		TotalAmount:   formatAmount(batch.TotalAmount, batch.CurrencyCode),
+	}
+}
+
+type transferBatchResponse struct {
+	Batch   transferBatchRowResponse     `json:"batch"`
+	Summary transferBatchSummaryResponse `json:"summary"`
+}
+
+func newTransferBatchResponse(batch db.TransferBatch, summary db.GetTransferBatchSummaryRow) transferBatchResponse {
+	return transferBatchResponse{
+		Batch: newTransferBatchRowResponse(batch),
+		Summary: transferBatchSummaryResponse{
+			GetTransferBatchSummaryRow: summary,
+			CompletedAmount:            formatAmount(summary.CompletedAmount, batch.CurrencyCode),
+			FailedAmount:               formatAmount(summary.FailedAmount, batch.CurrencyCode),
+		},
+	}
+}
+
+type transferBatchItemResponse struct {
+	db.TransferBatchItem
+	Amount string `json:"amount"`
+}
+
+type getTransferBatchRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransferBatch returns a batch with how many of its transfers are pending, made and failed
+func (server *Server) getTransferBatch(ctx *gin.Context) {
+	var req getTransferBatchRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, valid := server.ownedTransferBatch(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	summary, err := server.store.GetTransferBatchSummary(ctx, batch.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newTransferBatchResponse(batch, summary)))
+}
+
+type listTransferBatchesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listTransferBatches(ctx *gin.Context) {
+	var req listTransferBatchesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	batches, err := server.store.ListTransferBatches(ctx, db.ListTransferBatchesParams{
+		UserID: authPayload.UserID,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]transferBatchRowResponse, 0, len(batches))
+	for _, batch := range batches {
+		rsp = append(rsp, newTransferBatchRowResponse(batch))
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+// batches run to a thousand items, so items are listed in larger pages than elsewhere
+type listTransferBatchItemsRequest struct {
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+	Status   string `form:"status" binding:"omitempty,oneof=pending completed failed"`
+}
+
+// listTransferBatchItems lists a batch's items in file order, status=failed reports the ones that couldn't be made
+func (server *Server) listTransferBatchItems(ctx *gin.Context) {
+	var uri getTransferBatchRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listTransferBatchItemsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, valid := server.ownedTransferBatch(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	items, err := server.store.ListTransferBatchItems(ctx, db.ListTransferBatchItemsParams{
+		BatchID:    batch.ID,
+		Status:     pgtype.Text{String: req.Status, Valid: req.Status != ""},
+		PageSize:   req.PageSize,
+		PageOffset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]transferBatchItemResponse, 0, len(items))
+	for _, item := range items {
+		rsp = append(rsp, transferBatchItemResponse{
+			TransferBatchItem: item,
+			Amount:            formatAmount(item.Amount, batch.CurrencyCode),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+// ownedTransferBatch fetches a transfer batch and checks it belongs to the authenticated user
+func (server *Server) ownedTransferBatch(ctx *gin.Context, id int64) (db.TransferBatch, bool) {
+	batch, err := server.store.GetTransferBatch(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = fmt.Errorf("transfer batch with id %v doesnt exist", id)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return batch, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return batch, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if batch.UserID != authPayload.UserID {
+		err := errors.New("transfer batch doesnt belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return batch, false
+	}
+
+	return batch, true
+}
@@ -0,0 +1,529 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kelvinator07/golang-bank-microservices/bulk"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	"github.com/kelvinator07/golang-bank-microservices/worker"
+	mockwk "github.com/kelvinator07/golang-bank-microservices/worker/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateTransferBatchAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user1.ID = 1
+	user2, _ := randomUser(t)
+	user2.ID = 2
+
+	account1 := randomActiveAccount(user1.ID, 1, util.USD)
+	account2 := randomActiveAccount(user2.ID, 2, util.USD)
+	account3 := randomActiveAccount(user2.ID, 3, util.USD)
+	account4 := randomActiveAccount(user2.ID, 4, util.NGN)
+	frozenAccount := randomActiveAccount(user2.ID, 5, util.USD)
+	frozenAccount.Status = db.AccountStatusFrozen
+
+	csvFile := "to_account_id,amount,currency_code,reference\n2,10.50,USD,salary\n3,20,USD,salary\n"
+
+	testCases := []struct {
+		name          string
+		fields        map[string]string
+		file          string
+		buildStubs    func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "CSV",
+			fields: map[string]string{"from_account_id": "1", "format": db.TransferBatchFormatCSV},
+			file:   csvFile,
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					ListAccountsByIDs(gomock.Any(), gomock.Eq([]int64{account2.ID, account3.ID})).
+					Times(1).
+					Return([]db.Account{account2, account3}, nil)
+
+				batch := db.TransferBatch{
+					ID:            1,
+					UserID:        user1.ID,
+					FromAccountID: account1.ID,
+					CurrencyCode:  util.USD,
+					Format:        db.TransferBatchFormatCSV,
+					Status:        db.TransferBatchStatusPending,
+					ItemCount:     2,
+					TotalAmount:   3050,
+				}
+
+				store.EXPECT().
+					CreateTransferBatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateTransferBatchTxParams) (db.CreateTransferBatchTxResult, error) {
+						assert.Equal(t, user1.ID, arg.UserID)
+						assert.Equal(t, util.USD, arg.CurrencyCode)
+						assert.Equal(t, int64(2), arg.ItemCount)
+						assert.Equal(t, int64(3050), arg.TotalAmount)
+						assert.Equal(t, []db.CreateTransferBatchItemParams{
+							{LineNumber: 2, ToAccountID: account2.ID, Amount: 1050, Reference: "salary"},
+							{LineNumber: 3, ToAccountID: account3.ID, Amount: 2000, Reference: "salary"},
+						}, arg.Items)
+
+						return db.CreateTransferBatchTxResult{Batch: batch}, nil
+					})
+
+				distributor.EXPECT().
+					DistributeTaskProcessTransferBatch(gomock.Any(), gomock.Eq(&worker.PayloadProcessTransferBatch{BatchID: batch.ID}), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var res struct {
+					Data transferBatchResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), res.Data.Batch.ID)
+				assert.Equal(t, int64(2), res.Data.Summary.PendingItems)
+			},
+		},
+		{
+			name:   "Pain001",
+			fields: map[string]string{"from_account_id": "1", "format": db.TransferBatchFormatPain001},
+			file: `<Document><CstmrCdtTrfInitn><GrpHdr><NbOfTxs>1</NbOfTxs></GrpHdr><PmtInf>
+				<DbtrAcct><Id><Othr><Id>1</Id></Othr></Id></DbtrAcct>
+				<CdtTrfTxInf><PmtId><EndToEndId>E2E-1</EndToEndId></PmtId><Amt><InstdAmt Ccy="USD">5</InstdAmt></Amt>
+				<CdtrAcct><Id><Othr><Id>2</Id></Othr></Id></CdtrAcct></CdtTrfTxInf>
+				</PmtInf></CstmrCdtTrfInitn></Document>`,
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().ListAccountsByIDs(gomock.Any(), gomock.Any()).Times(1).Return([]db.Account{account2}, nil)
+
+				store.EXPECT().
+					CreateTransferBatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateTransferBatchTxParams) (db.CreateTransferBatchTxResult, error) {
+						assert.Equal(t, db.TransferBatchFormatPain001, arg.Format)
+						assert.Equal(t, []db.CreateTransferBatchItemParams{
+							{LineNumber: 1, ToAccountID: account2.ID, Amount: 500, Reference: "E2E-1"},
+						}, arg.Items)
+						return db.CreateTransferBatchTxResult{Batch: db.TransferBatch{ID: 2}}, nil
+					})
+
+				distributor.EXPECT().
+					DistributeTaskProcessTransferBatch(gomock.Any(), gomock.Eq(&worker.PayloadProcessTransferBatch{BatchID: 2}), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidLines",
+			fields: map[string]string{"from_account_id": "1", "format": db.TransferBatchFormatCSV},
+			file:   "to_account_id,amount,currency_code\n2,1.001,USD\n3,abc,USD\n",
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchLineErrors(t, recorder.Body, []int{2, 3})
+			},
+		},
+		{
+			name:   "InvalidAccounts",
+			fields: map[string]string{"from_account_id": "1", "format": db.TransferBatchFormatCSV},
+			file:   "to_account_id,amount,currency_code\n2,1,NGN\n1,1,USD\n4,1,USD\n5,1,USD\n6,1,USD\n3,1,USD\n",
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					ListAccountsByIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Account{account1, account2, account3, account4, frozenAccount}, nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+				// wrong currency, the from account, another currency's account, frozen and missing, the last line is fine
+				requireBodyMatchLineErrors(t, recorder.Body, []int{2, 3, 4, 5, 6})
+			},
+		},
+		{
+			name:   "TotalTooLarge",
+			fields: map[string]string{"from_account_id": "1", "format": db.TransferBatchFormatCSV},
+			file:   "to_account_id,amount,currency_code\n2,50000000000000000,USD\n3,50000000000000000,USD\n2,1,USD\n",
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					ListAccountsByIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Account{account2, account3}, nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+				// each amount fits on its own, the second line takes the total past what can be stored
+				requireBodyMatchLineErrors(t, recorder.Body, []int{3})
+			},
+		},
+		{
+			name:   "UnauthorizedUser",
+			fields: map[string]string{"from_account_id": "2", "format": db.TransferBatchFormatCSV},
+			file:   csvFile,
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "FromAccountNotFound",
+			fields: map[string]string{"from_account_id": "1", "format": db.TransferBatchFormatCSV},
+			file:   csvFile,
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidFormat",
+			fields: map[string]string{"from_account_id": "1", "format": "xlsx"},
+			file:   csvFile,
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "FileTooLarge",
+			fields: map[string]string{"from_account_id": "1", "format": db.TransferBatchFormatCSV},
+			file:   csvFile + strings.Repeat("2,1,USD,salary\n", maxTransferBatchFileSize/15),
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:   "QueueError",
+			fields: map[string]string{"from_account_id": "1", "format": db.TransferBatchFormatCSV},
+			file:   csvFile,
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().ListAccountsByIDs(gomock.Any(), gomock.Any()).Times(1).Return([]db.Account{account2, account3}, nil)
+
+				store.EXPECT().
+					CreateTransferBatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateTransferBatchTxResult{Batch: db.TransferBatch{ID: 1}}, nil)
+
+				distributor.EXPECT().
+					DistributeTaskProcessTransferBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(fmt.Errorf("redis is down"))
+
+				// the committed batch is failed so it doesn't stay pending without a task
+				arg := db.UpdateTransferBatchParams{
+					ID:     1,
+					Status: pgtype.Text{String: db.TransferBatchStatusFailed, Valid: true},
+				}
+				store.EXPECT().
+					UpdateTransferBatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, got db.UpdateTransferBatchParams) (db.TransferBatch, error) {
+						assert.Equal(t, arg.ID, got.ID)
+						assert.Equal(t, arg.Status, got.Status)
+						assert.True(t, got.CompletedAt.Valid)
+						return db.TransferBatch{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			distributor := mockwk.NewMockTaskDistributor(workerCtrl)
+			tc.buildStubs(store, distributor)
+
+			server := newTestServer(t, store, distributor)
+			recorder := httptest.NewRecorder()
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			for name, value := range tc.fields {
+				assert.NoError(t, writer.WriteField(name, value))
+			}
+			file, err := writer.CreateFormFile("file", "batch")
+			assert.NoError(t, err)
+			_, err = file.Write([]byte(tc.file))
+			assert.NoError(t, err)
+			assert.NoError(t, writer.Close())
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/transfer-batches", body)
+			assert.NoError(t, err)
+			request.Header.Set("Content-Type", writer.FormDataContentType())
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.ID, user1.AccountName, user1.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferBatchAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = 1
+
+	batch := db.TransferBatch{
+		ID:            1,
+		UserID:        user.ID,
+		FromAccountID: 1,
+		CurrencyCode:  util.USD,
+		Format:        db.TransferBatchFormatCSV,
+		Status:        db.TransferBatchStatusCompletedWithErrors,
+		ItemCount:     3,
+		TotalAmount:   600,
+	}
+	summary := db.GetTransferBatchSummaryRow{
+		CompletedItems:  2,
+		FailedItems:     1,
+		CompletedAmount: 300,
+		FailedAmount:    300,
+	}
+
+	testCases := []struct {
+		name          string
+		batchID       int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			batchID: batch.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().GetTransferBatchSummary(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(summary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var res struct {
+					Data transferBatchResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				assert.NoError(t, err)
+				assert.Equal(t, batch.Status, res.Data.Batch.Status)
+				requireBodyMatchData(t, recorder.Body.Bytes(), newTransferBatchResponse(batch, summary))
+				assert.Equal(t, "3.00", res.Data.Summary.FailedAmount)
+			},
+		},
+		{
+			name:    "NotFound",
+			batchID: batch.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(db.TransferBatch{}, db.ErrRecordNotFound)
+				store.EXPECT().GetTransferBatchSummary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:    "UnauthorizedUser",
+			batchID: 2,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(int64(2))).Times(1).Return(db.TransferBatch{ID: 2, UserID: 2}, nil)
+				store.EXPECT().GetTransferBatchSummary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:    "InvalidID",
+			batchID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/transfer-batches/%d", tc.batchID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListTransferBatchItemsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = 1
+
+	batch := db.TransferBatch{ID: 1, UserID: user.ID, CurrencyCode: util.NGN}
+	items := []db.TransferBatchItem{
+		{
+			ID:            3,
+			BatchID:       batch.ID,
+			LineNumber:    4,
+			ToAccountID:   5,
+			Amount:        300,
+			Status:        db.TransferBatchItemStatusFailed,
+			FailureReason: "account [5] is frozen",
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Failed",
+			query: "page_id=2&page_size=50&status=failed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+
+				arg := db.ListTransferBatchItemsParams{
+					BatchID:    batch.ID,
+					Status:     pgtype.Text{String: db.TransferBatchItemStatusFailed, Valid: true},
+					PageSize:   50,
+					PageOffset: 50,
+				}
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Eq(arg)).Times(1).Return(items, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				requireBodyMatchData(t, recorder.Body.Bytes(), []transferBatchItemResponse{
+					{TransferBatchItem: items[0], Amount: "3.00"},
+				})
+			},
+		},
+		{
+			name:  "AllStatuses",
+			query: "page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+
+				arg := db.ListTransferBatchItemsParams{BatchID: batch.ID, PageSize: 5}
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Eq(arg)).Times(1).Return(items, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidStatus",
+			query: "page_id=1&page_size=5&status=reversed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			query: "page_id=1&page_size=101",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/transfer-batches/%d/items?%s", batch.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchLineErrors(t *testing.T, body *bytes.Buffer, lines []int) {
+	var res struct {
+		Errors []bulk.LineError `json:"errors"`
+	}
+	err := json.Unmarshal(body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	actual := make([]int, len(res.Errors))
+	for i, lineErr := range res.Errors {
+		actual[i] = lineErr.Line
+	}
+	assert.Equal(t, lines, actual)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/token"
+)
+
+// getTransferLimits shows the authenticated user's tier limits and how much of each is left
+func (server *Server) getTransferLimits(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	result, err := server.store.GetRemainingTransferLimits(ctx, authPayload.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := transferLimitsResponse{
+		Tier:   result.Tier,
+		Limits: make([]remainingTransferLimitResponse, 0, len(result.Limits)),
+	}
+	for _, limit := range result.Limits {
+		rsp.Limits = append(rsp.Limits, newRemainingTransferLimitResponse(limit))
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(rsp))
+}
+
+type remainingTransferLimitResponse struct {
+	db.RemainingTransferLimit
+	PerTransaction   string `json:"per_transaction"`
+	Daily            string `json:"daily"`
+	DailyRemaining   string `json:"daily_remaining"`
+	Monthly          string `json:"monthly"`
+	MonthlyRemaining string `json:"monthly_remaining"`
+}
+
+func newRemainingTransferLimitResponse(limit db.RemainingTransferLimit) remainingTransferLimitResponse {
+	return remainingTransferLimitResponse{
+		RemainingTransferLimit: limit,
+		PerTransaction:         formatAmount(limit.PerTransaction, limit.CurrencyCode),
+		Daily:                  formatAmount(limit.Daily, limit.CurrencyCode),
+		DailyRemaining:         formatAmount(limit.DailyRemaining, limit.CurrencyCode),
+		Monthly:                formatAmount(limit.Monthly, limit.CurrencyCode),
+		MonthlyRemaining:       formatAmount(limit.MonthlyRemaining, limit.CurrencyCode),
+	}
+}
+
+type transferLimitsResponse struct {
+	Tier   string                           `json:"tier"`
+	Limits []remainingTransferLimitResponse `json:"limits"`
+}
+
+type transferLimitErrorDetail struct {
+	*db.TransferLimitError
+	Max       string `json:"max"`
+	Remaining string `json:"remaining"`
+}
+
+// transferLimitErrorResponse adds the limit that was hit to the error, so clients don't have to parse the message
+func transferLimitErrorResponse(err *db.TransferLimitError) gin.H {
+	rsp := errorResponse(err)
+	rsp["limit"] = transferLimitErrorDetail{
+		TransferLimitError: err,
+		Max:                formatAmount(err.Max, err.CurrencyCode),
+		Remaining:          formatAmount(err.Remaining, err.CurrencyCode),
+	}
+	return rsp
+}
+
+type setTransferLimitUriRequest struct {
+	Tier         string `uri:"tier" binding:"required,oneof=standard premium"`
+	CurrencyCode string `uri:"currency_code" binding:"required,currencyCode"`
+}
+
+type setTransferLimitRequest struct {
+	PerTransaction string `json:"per_transaction" binding:"required,amount"`
+	Daily          string `json:"daily" binding:"required,amount"`
+	Monthly        string `json:"monthly" binding:"required,amount"`
+}
+
+type transferLimitResponse struct {
+	db.TransferLimit
+	PerTransaction string `json:"per_transaction"`
+	Daily          string `json:"daily"`
+	Monthly        string `json:"monthly"`
+}
+
+func newTransferLimitResponse(limit db.TransferLimit) transferLimitResponse {
+	return transferLimitResponse{
+		TransferLimit:  limit,
+		PerTransaction: formatAmount(limit.PerTransaction, limit.CurrencyCode),
+		Daily:          formatAmount(limit.Daily, limit.CurrencyCode),
+		Monthly:        formatAmount(limit.Monthly, limit.CurrencyCode),
+	}
+}
+
+// setTransferLimit sets a tier's limits for a currency, adding them if the currency had none.
+// Every user on the tier gets the new limits with their next transfer
+func (server *Server) setTransferLimit(ctx *gin.Context) {
+	var uri setTransferLimitUriRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req setTransferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	perTransaction, valid := parseAmount(ctx, req.PerTransaction, uri.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	daily, valid := parseAmount(ctx, req.Daily, uri.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	monthly, valid := parseAmount(ctx, req.Monthly, uri.CurrencyCode)
+	if !valid {
+		return
+	}
+
+	// the same order the transfer_limits check constraint enforces
+	if daily < perTransaction || monthly < daily {
+		err := errors.New("limits must satisfy per_transaction <= daily <= monthly")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	limit, err := server.store.UpsertTransferLimit(ctx, db.UpsertTransferLimitParams{
+		Tier:           uri.Tier,
+		CurrencyCode:   uri.CurrencyCode,
+		PerTransaction: perTransaction,
+		Daily:          daily,
+		Monthly:        monthly,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validResponse(newTransferLimitResponse(limit)))
+}
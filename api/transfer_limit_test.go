@@ -0,0 +1,279 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mockdb "github.com/kelvinator07/golang-bank-microservices/db/mock"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/token"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	mockwk "github.com/kelvinator07/golang-bank-microservices/worker/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetTransferLimitsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.ID = 1
+
+	limits := db.GetRemainingTransferLimitsResult{
+		Tier: db.UserTierStandard,
+		Limits: []db.RemainingTransferLimit{
+			{
+				CurrencyCode:     util.USD,
+				PerTransaction:   5000,
+				Daily:            10000,
+				DailyRemaining:   7500,
+				Monthly:          50000,
+				MonthlyRemaining: 42500,
+			},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetRemainingTransferLimits(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(limits, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var res struct {
+					Data transferLimitsResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				assert.NoError(t, err)
+				assert.Equal(t, limits.Tier, res.Data.Tier)
+				assert.Len(t, res.Data.Limits, 1)
+				assert.Equal(t, "50.00", res.Data.Limits[0].PerTransaction)
+				assert.Equal(t, "75.00", res.Data.Limits[0].DailyRemaining)
+				assert.Equal(t, "425.00", res.Data.Limits[0].MonthlyRemaining)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.AccountName, user.Email, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetRemainingTransferLimits(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetRemainingTransferLimitsResult{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetRemainingTransferLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/transfer-limits", nil)
+			assert.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestSetTransferLimitAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.ID = 1
+	admin.Role = db.UserRoleAdmin
+	customer, _ := randomUser(t)
+	customer.ID = 2
+	customer.Role = db.UserRoleCustomer
+
+	limit := db.TransferLimit{
+		Tier:           db.UserTierStandard,
+		CurrencyCode:   util.USD,
+		PerTransaction: 500000,
+		Daily:          1000000,
+		Monthly:        5000000,
+	}
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		tier          string
+		currencyCode  string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OK",
+			user:         admin,
+			tier:         limit.Tier,
+			currencyCode: limit.CurrencyCode,
+			body:         gin.H{"per_transaction": "5000", "daily": "10000.00", "monthly": "50000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertTransferLimitParams{
+					Tier:           limit.Tier,
+					CurrencyCode:   limit.CurrencyCode,
+					PerTransaction: limit.PerTransaction,
+					Daily:          limit.Daily,
+					Monthly:        limit.Monthly,
+				}
+				store.EXPECT().UpsertTransferLimit(gomock.Any(), gomock.Eq(arg)).Times(1).Return(limit, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, recorder.Code)
+
+				var res struct {
+					Data transferLimitResponse `json:"data"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				assert.NoError(t, err)
+				assert.Equal(t, "5000.00", res.Data.PerTransaction)
+				assert.Equal(t, "10000.00", res.Data.Daily)
+				assert.Equal(t, "50000.00", res.Data.Monthly)
+			},
+		},
+		{
+			name:         "NotAdmin",
+			user:         customer,
+			tier:         limit.Tier,
+			currencyCode: limit.CurrencyCode,
+			body:         gin.H{"per_transaction": "5000", "daily": "10000", "monthly": "50000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:         "UnknownTier",
+			user:         admin,
+			tier:         "gold",
+			currencyCode: limit.CurrencyCode,
+			body:         gin.H{"per_transaction": "5000", "daily": "10000", "monthly": "50000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "UnknownCurrency",
+			user:         admin,
+			tier:         limit.Tier,
+			currencyCode: "XYZ",
+			body:         gin.H{"per_transaction": "5000", "daily": "10000", "monthly": "50000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "DailyBelowPerTransaction",
+			user:         admin,
+			tier:         limit.Tier,
+			currencyCode: limit.CurrencyCode,
+			body:         gin.H{"per_transaction": "5000", "daily": "1000", "monthly": "50000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "TooManyDecimalPlaces",
+			user:         admin,
+			tier:         limit.Tier,
+			currencyCode: limit.CurrencyCode,
+			body:         gin.H{"per_transaction": "5000.001", "daily": "10000", "monthly": "50000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(tc.user.ID)).Times(1).Return(tc.user, nil)
+			tc.buildStubs(store)
+
+			workerCtrl := gomock.NewController(t)
+			defer workerCtrl.Finish()
+
+			worker := mockwk.NewMockTaskDistributor(workerCtrl)
+
+			server := newTestServer(t, store, worker)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			assert.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/admin/transfer-limits/%s/%s", tc.tier, tc.currencyCode)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			assert.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.ID, tc.user.AccountName, tc.user.Email, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -32,6 +32,7 @@ type createUserResponse struct {
 	Gender      string    `json:"gender"`
 	PhoneNumber int64     `json:"phone_number"`
 	Email       string    `json:"email"`
+	Tier        string    `json:"tier"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
@@ -43,6 +44,7 @@ func newUserResponse(user db.User) createUserResponse {
 		Gender:      user.Gender,
 		PhoneNumber: user.PhoneNumber,
 		Email:       user.Email,
+		Tier:        user.Tier,
 		CreatedAt:   user.CreatedAt,
 	}
 }
@@ -160,13 +162,14 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.ID, user.AccountName, user.Email, server.config.AccessTokenDuration)
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.ID, user.AccountName, user.Email, uuid.Nil, server.config.RefreshTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.ID, user.AccountName, user.Email, server.config.RefreshTokenDuration)
+	// the access token is bound to the session the refresh token identifies
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.ID, user.AccountName, user.Email, refreshPayload.ID, server.config.AccessTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -174,6 +177,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
+		FamilyID:     refreshPayload.ID,
 		Email:        user.Email,
 		RefreshToken: refreshToken,
 		UserAgent:    ctx.Request.UserAgent(),
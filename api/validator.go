@@ -9,9 +9,10 @@ import (
 	"github.com/kelvinator07/golang-bank-microservices/util"
 )
 
+// validCurrency accepts the currencies an admin has enabled in the currency registry
 var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 	if currency, ok := fl.Field().Interface().(string); ok {
-		return util.IsSupportedCurrency(currency)
+		return util.IsEnabledCurrency(currency)
 	}
 	return false
 }
@@ -0,0 +1,128 @@
+// Package bulk reads the files payroll customers upload to pay many accounts at once
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+// MaxItems is the most transfers one file may hold
+const MaxItems = 1000
+
+const maxReferenceLength = 140
+
+// Item is one transfer in an uploaded file
+type Item struct {
+	Line         int    `json:"line"`
+	ToAccountID  int64  `json:"to_account_id"`
+	Amount       int64  `json:"amount"`
+	CurrencyCode string `json:"currency_code"`
+	Reference    string `json:"reference"`
+}
+
+// LineError explains why a line of the file was rejected, line 0 is the file as a whole
+type LineError struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError lists every invalid line, so a file can be fixed in one go rather than line by line
+type ValidationError struct {
+	Lines []LineError `json:"lines"`
+}
+
+func (err *ValidationError) Error() string {
+	if len(err.Lines) == 1 {
+		return fmt.Sprintf("batch file is invalid: %s", err.Lines[0])
+	}
+	return fmt.Sprintf("batch file has %d errors, the first is %s", len(err.Lines), err.Lines[0])
+}
+
+func (lineErr LineError) String() string {
+	if lineErr.Line == 0 {
+		return lineErr.Reason
+	}
+	return fmt.Sprintf("line %d: %s", lineErr.Line, lineErr.Reason)
+}
+
+// Add records a problem with a line, reasons are formatted like fmt.Sprintf
+func (err *ValidationError) Add(line int, format string, args ...any) {
+	err.Lines = append(err.Lines, LineError{Line: line, Reason: fmt.Sprintf(format, args...)})
+}
+
+// Err returns nil when no line was rejected
+func (err *ValidationError) Err() error {
+	if len(err.Lines) == 0 {
+		return nil
+	}
+	return err
+}
+
+// ParseAmount converts a positive decimal amount such as 1500.50 to minor units of the currency,
+// more decimal places than the currency has are rejected
+func ParseAmount(amount string, currencyCode string) (int64, error) {
+	if strings.HasPrefix(amount, "-") {
+		return 0, fmt.Errorf("amount %q is not a decimal number", amount)
+	}
+
+	money, err := util.CurrencyFor(currencyCode).Parse(amount)
+	if err != nil {
+		return 0, err
+	}
+	if money.Amount == 0 {
+		return 0, errors.New("amount must be greater than zero")
+	}
+
+	return money.Amount, nil
+}
+
+// validate applies the checks every format shares once a line has been read
+func validate(validationErr *ValidationError, item Item, toAccountID string, amount string) (Item, bool) {
+	valid := true
+
+	id, err := strconv.ParseInt(strings.TrimSpace(toAccountID), 10, 64)
+	if err != nil || id < 1 {
+		validationErr.Add(item.Line, "to_account_id %q is not a valid account id", toAccountID)
+		valid = false
+	}
+	item.ToAccountID = id
+
+	// amounts in a currency that is missing or unknown are still checked, as if it had the usual two decimal places
+	item.CurrencyCode = strings.TrimSpace(item.CurrencyCode)
+	item.Amount, err = ParseAmount(strings.TrimSpace(amount), item.CurrencyCode)
+	if err != nil {
+		validationErr.Add(item.Line, "%s", err)
+		valid = false
+	}
+
+	switch {
+	case item.CurrencyCode == "":
+		validationErr.Add(item.Line, "currency is required")
+		valid = false
+	case !util.IsEnabledCurrency(item.CurrencyCode):
+		validationErr.Add(item.Line, "currency %q is not supported", item.CurrencyCode)
+		valid = false
+	}
+
+	item.Reference = strings.TrimSpace(item.Reference)
+	if len(item.Reference) > maxReferenceLength {
+		validationErr.Add(item.Line, "reference is longer than %d characters", maxReferenceLength)
+		valid = false
+	}
+
+	return item, valid
+}
+
+// checkCount rejects files with no transfers or more than MaxItems
+func checkCount(validationErr *ValidationError, count int) {
+	switch {
+	case count == 0:
+		validationErr.Add(0, "batch file has no transfers")
+	case count > MaxItems:
+		validationErr.Add(0, "batch file has %d transfers, at most %d are allowed", count, MaxItems)
+	}
+}
@@ -0,0 +1,188 @@
+package bulk
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var expectedItems = []Item{
+	{Line: 2, ToAccountID: 12, Amount: 150000, CurrencyCode: "NGN", Reference: "March salary"},
+	{Line: 3, ToAccountID: 13, Amount: 225050, CurrencyCode: "NGN", Reference: "March salary, overtime"},
+	{Line: 4, ToAccountID: 14, Amount: 9900, CurrencyCode: "NGN", Reference: ""},
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		expected int64
+		valid    bool
+	}{
+		{"1500", 150000, true},
+		{"1500.5", 150050, true},
+		{"0.01", 1, true},
+		{"007.10", 710, true},
+		{"0", 0, false},
+		{"0.00", 0, false},
+		{"-10", 0, false},
+		{"+10", 0, false},
+		{"10.", 0, false},
+		{".5", 0, false},
+		{"1.001", 0, false},
+		{"1,50", 0, false},
+		{"1e3", 0, false},
+		{"", 0, false},
+		{"99999999999999999999", 0, false},
+	}
+
+	for _, tc := range testCases {
+		amount, err := ParseAmount(tc.amount, "NGN")
+		if tc.valid {
+			assert.NoError(t, err, tc.amount)
+			assert.Equal(t, tc.expected, amount, tc.amount)
+		} else {
+			assert.Error(t, err, tc.amount)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	file, err := os.Open("testdata/batch.csv")
+	assert.NoError(t, err)
+	defer file.Close()
+
+	items, err := ParseCSV(file)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedItems, items)
+}
+
+func TestParseCSVColumnOrder(t *testing.T) {
+	items, err := ParseCSV(strings.NewReader("Amount, Currency_Code, To_Account_ID\n1.50,USD,3\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{{Line: 2, ToAccountID: 3, Amount: 150, CurrencyCode: "USD"}}, items)
+}
+
+func TestParseCSVInvalid(t *testing.T) {
+	file, err := os.Open("testdata/invalid.csv")
+	assert.NoError(t, err)
+	defer file.Close()
+
+	_, err = ParseCSV(file)
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []LineError{
+		{Line: 2, Reason: `amount "1500.001" has more than 2 decimal places`},
+		{Line: 3, Reason: `to_account_id "abc" is not a valid account id`},
+		{Line: 4, Reason: "amount must be greater than zero"},
+		{Line: 4, Reason: "currency is required"},
+		{Line: 5, Reason: "row has 2 fields, the header has 4"},
+	}, validationErr.Lines)
+	assert.Equal(t, `batch file has 5 errors, the first is line 2: amount "1500.001" has more than 2 decimal places`, err.Error())
+}
+
+func TestParseCSVFile(t *testing.T) {
+	testCases := []struct {
+		name   string
+		file   string
+		reason string
+	}{
+		{"Empty", "", "batch file is empty"},
+		{"MissingColumn", "to_account_id,currency_code\n", "header is missing the amount column"},
+		{"UnsupportedCurrency", "to_account_id,amount,currency_code\n1,1,GBP\n", `currency "GBP" is not supported`},
+		{"NoTransfers", "to_account_id,amount,currency_code\n", "batch file has no transfers"},
+		{"TooManyTransfers", "to_account_id,amount,currency_code\n" + strings.Repeat("1,1,NGN\n", MaxItems+1),
+			"batch file has 1001 transfers, at most 1000 are allowed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(tc.file))
+
+			var validationErr *ValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Len(t, validationErr.Lines, 1)
+			assert.Equal(t, tc.reason, validationErr.Lines[0].Reason)
+		})
+	}
+}
+
+func TestParsePain001(t *testing.T) {
+	file, err := os.Open("testdata/batch.pain001.xml")
+	assert.NoError(t, err)
+	defer file.Close()
+
+	items, err := ParsePain001(file, 7)
+	assert.NoError(t, err)
+
+	// pain.001 transfers are numbered by position, the references are the end to end ids or else the remittance info
+	expected := make([]Item, len(expectedItems))
+	copy(expected, expectedItems)
+	for i := range expected {
+		expected[i].Line = i + 1
+	}
+	expected[0].Reference = "SALARY-12"
+	expected[2].Reference = "SALARY-14"
+	assert.Equal(t, expected, items)
+}
+
+func TestParsePain001Invalid(t *testing.T) {
+	document, err := os.ReadFile("testdata/batch.pain001.xml")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		file          string
+		fromAccountID int64
+		errors        []LineError
+	}{
+		{
+			name:          "OtherDebtorAccount",
+			file:          string(document),
+			fromAccountID: 8,
+			errors:        []LineError{{Line: 0, Reason: "payment PAYROLL-2024-03-1 debits account 7, the batch is for account 8"}},
+		},
+		{
+			name:          "NbOfTxs",
+			file:          strings.Replace(string(document), "<NbOfTxs>3</NbOfTxs>", "<NbOfTxs>2</NbOfTxs>", 1),
+			fromAccountID: 7,
+			errors:        []LineError{{Line: 0, Reason: "group header NbOfTxs is 2 but the file has 3 transfers"}},
+		},
+		{
+			name:          "CtrlSum",
+			file:          strings.Replace(string(document), "<CtrlSum>3849.50</CtrlSum>", "<CtrlSum>3849</CtrlSum>", 1),
+			fromAccountID: 7,
+			errors:        []LineError{{Line: 0, Reason: "group header CtrlSum is 3849 but the transfers add up to 3849.50"}},
+		},
+		{
+			name:          "InvalidTransfer",
+			file:          strings.Replace(string(document), `<InstdAmt Ccy="NGN">99</InstdAmt>`, `<InstdAmt>-99</InstdAmt>`, 1),
+			fromAccountID: 7,
+			errors: []LineError{
+				{Line: 3, Reason: `amount "-99" is not a decimal number`},
+				{Line: 3, Reason: "currency is required"},
+			},
+		},
+		{
+			name:          "NotPain001",
+			file:          "<Document><BkToCstmrStmt></Document>",
+			fromAccountID: 7,
+			errors: []LineError{
+				{Line: 0, Reason: "batch file is not a pain.001 document: XML syntax error on line 1: element <BkToCstmrStmt> closed by </Document>"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParsePain001(strings.NewReader(tc.file), tc.fromAccountID)
+
+			var validationErr *ValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tc.errors, validationErr.Lines)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// the reference column may be left out, the others are required and can come in any order
+var csvColumns = []string{"to_account_id", "amount", "currency_code", "reference"}
+
+// ParseCSV reads a file with a header row naming the csv columns and one transfer per row
+// Every row is checked, a *ValidationError lists all the rows that are invalid
+func ParseCSV(r io.Reader) ([]Item, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	validationErr := &ValidationError{}
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("batch file is empty")
+		}
+		validationErr.Add(0, "%s", err)
+		return nil, validationErr
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvColumns[:3] {
+		if _, ok := columns[name]; !ok {
+			validationErr.Add(1, "header is missing the %s column", name)
+		}
+	}
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return record[i]
+		}
+		return ""
+	}
+
+	var items []Item
+	count := 0
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			if errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				validationErr.Add(parseErr.StartLine, "row has %d fields, the header has %d", len(record), len(header))
+				count++
+				continue
+			}
+			// the reader can't find where the next row starts after a malformed one
+			validationErr.Add(parseErr.StartLine, "%s", parseErr.Err)
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+		count++
+		item, valid := validate(validationErr, Item{
+			Line:         line,
+			CurrencyCode: field(record, "currency_code"),
+			Reference:    field(record, "reference"),
+		}, field(record, "to_account_id"), field(record, "amount"))
+		if valid {
+			items = append(items, item)
+		}
+	}
+
+	checkCount(validationErr, count)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,120 @@
+package bulk
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+// the elements below are the ones a pain.001 customer credit transfer initiation needs for a transfer between
+// accounts at the bank, anything else in the file is ignored. Elements are matched by name so any
+// pain.001.001 version is read
+type pain001Document struct {
+	XMLName  xml.Name          `xml:"Document"`
+	Initiate pain001Initiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001Initiation struct {
+	GrpHdr pain001GroupHeader `xml:"GrpHdr"`
+	PmtInf []pain001Payment   `xml:"PmtInf"`
+}
+
+type pain001GroupHeader struct {
+	MsgID   string `xml:"MsgId"`
+	NbOfTxs string `xml:"NbOfTxs"`
+	CtrlSum string `xml:"CtrlSum"`
+}
+
+type pain001Payment struct {
+	PmtInfID    string               `xml:"PmtInfId"`
+	DbtrAcct    string               `xml:"DbtrAcct>Id>Othr>Id"`
+	CdtTrfTxInf []pain001Transaction `xml:"CdtTrfTxInf"`
+}
+
+type pain001Transaction struct {
+	EndToEndID string        `xml:"PmtId>EndToEndId"`
+	InstdAmt   pain001Amount `xml:"Amt>InstdAmt"`
+	CdtrAcct   string        `xml:"CdtrAcct>Id>Othr>Id"`
+	Ustrd      string        `xml:"RmtInf>Ustrd"`
+}
+
+type pain001Amount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ParsePain001 reads an ISO 20022 pain.001 file, the transfers are numbered by their position in the file
+// Every payment must be made from fromAccountID when it names a debtor account. The group header's
+// NbOfTxs and CtrlSum have to add up. A *ValidationError lists all the transfers that are invalid
+func ParsePain001(r io.Reader, fromAccountID int64) ([]Item, error) {
+	validationErr := &ValidationError{}
+
+	var document pain001Document
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		// anything other than a malformed document is a problem reading the upload
+		var syntaxErr *xml.SyntaxError
+		var unmarshalErr xml.UnmarshalError
+		if !errors.As(err, &syntaxErr) && !errors.As(err, &unmarshalErr) && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		validationErr.Add(0, "batch file is not a pain.001 document: %s", err)
+		return nil, validationErr
+	}
+
+	var items []Item
+	var total int64
+	count := 0
+
+	for _, payment := range document.Initiate.PmtInf {
+		debtor := strings.TrimSpace(payment.DbtrAcct)
+		if debtor != "" && debtor != strconv.FormatInt(fromAccountID, 10) {
+			validationErr.Add(0, "payment %s debits account %s, the batch is for account %d", payment.PmtInfID, debtor, fromAccountID)
+		}
+
+		for _, transaction := range payment.CdtTrfTxInf {
+			count++
+
+			reference := strings.TrimSpace(transaction.EndToEndID)
+			if reference == "" || reference == "NOTPROVIDED" {
+				reference = transaction.Ustrd
+			}
+
+			item, valid := validate(validationErr, Item{
+				Line:         count,
+				CurrencyCode: transaction.InstdAmt.Ccy,
+				Reference:    reference,
+			}, transaction.CdtrAcct, transaction.InstdAmt.Value)
+			if valid {
+				items = append(items, item)
+				total += item.Amount
+			}
+		}
+	}
+
+	checkCount(validationErr, count)
+
+	header := document.Initiate.GrpHdr
+	if header.NbOfTxs != "" && header.NbOfTxs != strconv.Itoa(count) {
+		validationErr.Add(0, "group header NbOfTxs is %s but the file has %d transfers", header.NbOfTxs, count)
+	}
+	// the control sum only means something once every amount could be read, every transfer of a batch is in one currency
+	if header.CtrlSum != "" && count > 0 && len(items) == count {
+		currencyCode := items[0].CurrencyCode
+		ctrlSum, err := ParseAmount(strings.TrimSpace(header.CtrlSum), currencyCode)
+		if err != nil {
+			validationErr.Add(0, "group header CtrlSum: %s", err)
+		} else if ctrlSum != total {
+			validationErr.Add(0, "group header CtrlSum is %s but the transfers add up to %s", header.CtrlSum, util.NewMoney(total, currencyCode))
+		}
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,17 @@
+CREATE TABLE "idempotency_keys" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "key" varchar NOT NULL,
+  "request_path" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response_code" int NOT NULL DEFAULT 0,
+  "response_body" jsonb,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX ON "idempotency_keys" ("user_id", "key");
+
+COMMENT ON COLUMN "idempotency_keys"."response_code" IS '0 while the original request is still in flight';
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "account_status_history";
+
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "accounts_status_check";
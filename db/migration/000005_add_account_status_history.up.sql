@@ -0,0 +1,19 @@
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_status_check" CHECK ("status" IN ('inactive', 'active', 'frozen', 'closed'));
+
+CREATE TABLE "account_status_history" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "from_status" varchar NOT NULL,
+  "to_status" varchar NOT NULL,
+  "changed_by" bigint NOT NULL,
+  "reason" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "account_status_history" ("account_id");
+
+COMMENT ON COLUMN "account_status_history"."changed_by" IS 'id of the user that made the change';
+
+ALTER TABLE "account_status_history" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_status_history" ADD FOREIGN KEY ("changed_by") REFERENCES "users" ("id");
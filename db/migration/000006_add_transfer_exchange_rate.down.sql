@@ -0,0 +1,5 @@
+ALTER TABLE "transfers" DROP COLUMN "exchange_rate";
+
+ALTER TABLE "transfers" DROP COLUMN "to_amount";
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
@@ -0,0 +1,5 @@
+ALTER TABLE "transfers" DROP COLUMN "reversed_amount";
+
+ALTER TABLE "transfers" DROP COLUMN "reversal_of";
+
+ALTER TABLE "transfers" DROP COLUMN "status";
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS "entries_transfer_balance" ON "entries";
+
+DROP TRIGGER IF EXISTS "transfers_entries_balance" ON "transfers";
+
+DROP FUNCTION IF EXISTS check_transfer_entries();
+
+ALTER TABLE "entries" DROP COLUMN "transfer_id";
@@ -0,0 +1,58 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer that wrote the entry, null for entries written before transfers were linked';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+-- the debit entries of a transfer must add up to amount and its credit entries to to_amount,
+-- so a same currency transfer always nets to zero. Checked at commit, once all the entries are written
+CREATE FUNCTION check_transfer_entries() RETURNS trigger AS $$
+DECLARE
+  checked_transfer_id bigint;
+  transfer_amount bigint;
+  transfer_to_amount bigint;
+  debits bigint;
+  credits bigint;
+BEGIN
+  IF TG_TABLE_NAME = 'transfers' THEN
+    checked_transfer_id := NEW.id;
+  ELSIF TG_OP = 'DELETE' THEN
+    checked_transfer_id := OLD.transfer_id;
+  ELSE
+    checked_transfer_id := NEW.transfer_id;
+  END IF;
+
+  IF checked_transfer_id IS NULL THEN
+    RETURN NULL;
+  END IF;
+
+  SELECT amount, to_amount INTO transfer_amount, transfer_to_amount
+  FROM transfers WHERE id = checked_transfer_id;
+
+  SELECT
+    COALESCE(SUM(-amount) FILTER (WHERE amount < 0), 0),
+    COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0)
+  INTO debits, credits
+  FROM entries WHERE transfer_id = checked_transfer_id;
+
+  IF debits <> transfer_amount OR credits <> transfer_to_amount THEN
+    RAISE EXCEPTION 'entries of transfer % do not balance: debits %, credits %, expected % and %',
+      checked_transfer_id, debits, credits, transfer_amount, transfer_to_amount
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "transfers_entries_balance"
+  AFTER INSERT OR UPDATE OF "amount", "to_amount" ON "transfers"
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION check_transfer_entries();
+
+CREATE CONSTRAINT TRIGGER "entries_transfer_balance"
+  AFTER INSERT OR UPDATE OR DELETE ON "entries"
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION check_transfer_entries();
@@ -0,0 +1,3 @@
+ALTER TABLE "accounts" DROP COLUMN "overdraft_limit";
+
+ALTER TABLE "users" DROP COLUMN "role";
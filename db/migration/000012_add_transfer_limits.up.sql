@@ -0,0 +1,29 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+CREATE TABLE "transfer_limits" (
+  "tier" varchar NOT NULL,
+  "currency_code" varchar NOT NULL,
+  "per_transaction" bigint NOT NULL,
+  "daily" bigint NOT NULL,
+  "monthly" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("tier", "currency_code")
+);
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_check" CHECK ("per_transaction" > 0 AND "daily" >= "per_transaction" AND "monthly" >= "daily");
+
+COMMENT ON COLUMN "transfer_limits"."per_transaction" IS 'largest single transfer, in currency_code';
+
+COMMENT ON COLUMN "transfer_limits"."daily" IS 'total a user can send per UTC calendar day from their currency_code accounts';
+
+COMMENT ON COLUMN "transfer_limits"."monthly" IS 'total a user can send per UTC calendar month from their currency_code accounts';
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+INSERT INTO "transfer_limits" ("tier", "currency_code", "per_transaction", "daily", "monthly") VALUES
+  ('standard', 'USD', 5000, 10000, 50000),
+  ('standard', 'EUR', 5000, 10000, 50000),
+  ('standard', 'NGN', 2000000, 5000000, 20000000),
+  ('premium', 'USD', 50000, 100000, 500000),
+  ('premium', 'EUR', 50000, 100000, 500000),
+  ('premium', 'NGN', 20000000, 50000000, 200000000);
@@ -0,0 +1,51 @@
+CREATE OR REPLACE FUNCTION check_transfer_entries() RETURNS trigger AS $$
+DECLARE
+  checked_transfer_id bigint;
+  transfer_amount bigint;
+  transfer_to_amount bigint;
+  debits bigint;
+  credits bigint;
+BEGIN
+  IF TG_TABLE_NAME = 'transfers' THEN
+    checked_transfer_id := NEW.id;
+  ELSIF TG_OP = 'DELETE' THEN
+    checked_transfer_id := OLD.transfer_id;
+  ELSE
+    checked_transfer_id := NEW.transfer_id;
+  END IF;
+
+  IF checked_transfer_id IS NULL THEN
+    RETURN NULL;
+  END IF;
+
+  SELECT amount, to_amount INTO transfer_amount, transfer_to_amount
+  FROM transfers WHERE id = checked_transfer_id;
+
+  SELECT
+    COALESCE(SUM(-amount) FILTER (WHERE amount < 0), 0),
+    COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0)
+  INTO debits, credits
+  FROM entries WHERE transfer_id = checked_transfer_id;
+
+  IF debits <> transfer_amount OR credits <> transfer_to_amount THEN
+    RAISE EXCEPTION 'entries of transfer % do not balance: debits %, credits %, expected % and %',
+      checked_transfer_id, debits, credits, transfer_amount, transfer_to_amount
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS "fee_rules";
+
+ALTER TABLE "transfers" DROP COLUMN "fee";
+
+-- only works before any fee was charged, the entries of the fee revenue accounts would be orphaned
+DELETE FROM "accounts" WHERE "user_id" IS NULL;
+
+DROP INDEX IF EXISTS "accounts_kind_currency_code_idx";
+
+ALTER TABLE "accounts" DROP COLUMN "kind";
+
+ALTER TABLE "accounts" ALTER COLUMN "user_id" SET NOT NULL;
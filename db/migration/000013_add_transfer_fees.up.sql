@@ -0,0 +1,80 @@
+ALTER TABLE "accounts" ALTER COLUMN "user_id" DROP NOT NULL;
+
+ALTER TABLE "accounts" ADD COLUMN "kind" varchar NOT NULL DEFAULT 'customer';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_kind_check" CHECK ("kind" IN ('customer', 'fee_revenue'));
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_user_id_check" CHECK (("kind" = 'customer') = ("user_id" IS NOT NULL));
+
+CREATE UNIQUE INDEX ON "accounts" ("kind", "currency_code") WHERE "user_id" IS NULL;
+
+COMMENT ON COLUMN "accounts"."kind" IS 'customer accounts belong to user_id, the other kinds are system accounts the bank owns, one per currency';
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_fee_check" CHECK ("fee" >= 0);
+
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to from_account_id on top of amount, in its currency, and credited to its fee revenue account';
+
+CREATE TABLE "fee_rules" (
+  "id" bigserial PRIMARY KEY,
+  "currency_code" varchar NOT NULL,
+  "min_amount" bigint NOT NULL DEFAULT 0,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "percentage_bps" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "fee_rules" ("currency_code", "min_amount");
+
+ALTER TABLE "fee_rules" ADD CONSTRAINT "fee_rules_check" CHECK ("min_amount" >= 0 AND "flat_fee" >= 0 AND "percentage_bps" BETWEEN 0 AND 10000);
+
+COMMENT ON COLUMN "fee_rules"."min_amount" IS 'the rule applies to transfers of at least this amount, until a rule with a higher min_amount takes over';
+
+COMMENT ON COLUMN "fee_rules"."percentage_bps" IS 'basis points of the amount, added to flat_fee and truncated';
+
+INSERT INTO "accounts" ("user_id", "account_number", "status", "balance", "currency_code", "kind") VALUES
+  (NULL, 0, 'active', 0, 'USD', 'fee_revenue'),
+  (NULL, 0, 'active', 0, 'EUR', 'fee_revenue'),
+  (NULL, 0, 'active', 0, 'NGN', 'fee_revenue');
+
+-- the fee is debited from the sender and credited to the fee revenue account, on top of amount and to_amount
+CREATE OR REPLACE FUNCTION check_transfer_entries() RETURNS trigger AS $$
+DECLARE
+  checked_transfer_id bigint;
+  transfer_amount bigint;
+  transfer_to_amount bigint;
+  transfer_fee bigint;
+  debits bigint;
+  credits bigint;
+BEGIN
+  IF TG_TABLE_NAME = 'transfers' THEN
+    checked_transfer_id := NEW.id;
+  ELSIF TG_OP = 'DELETE' THEN
+    checked_transfer_id := OLD.transfer_id;
+  ELSE
+    checked_transfer_id := NEW.transfer_id;
+  END IF;
+
+  IF checked_transfer_id IS NULL THEN
+    RETURN NULL;
+  END IF;
+
+  SELECT amount, to_amount, fee INTO transfer_amount, transfer_to_amount, transfer_fee
+  FROM transfers WHERE id = checked_transfer_id;
+
+  SELECT
+    COALESCE(SUM(-amount) FILTER (WHERE amount < 0), 0),
+    COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0)
+  INTO debits, credits
+  FROM entries WHERE transfer_id = checked_transfer_id;
+
+  IF debits <> transfer_amount + transfer_fee OR credits <> transfer_to_amount + transfer_fee THEN
+    RAISE EXCEPTION 'entries of transfer % do not balance: debits %, credits %, expected % and % with fee %',
+      checked_transfer_id, debits, credits, transfer_amount, transfer_to_amount, transfer_fee
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
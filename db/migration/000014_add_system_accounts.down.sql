@@ -0,0 +1,47 @@
+-- the fee is debited from the sender and credited to the fee revenue account, on top of amount and to_amount
+CREATE OR REPLACE FUNCTION check_transfer_entries() RETURNS trigger AS $$
+DECLARE
+  checked_transfer_id bigint;
+  transfer_amount bigint;
+  transfer_to_amount bigint;
+  transfer_fee bigint;
+  debits bigint;
+  credits bigint;
+BEGIN
+  IF TG_TABLE_NAME = 'transfers' THEN
+    checked_transfer_id := NEW.id;
+  ELSIF TG_OP = 'DELETE' THEN
+    checked_transfer_id := OLD.transfer_id;
+  ELSE
+    checked_transfer_id := NEW.transfer_id;
+  END IF;
+
+  IF checked_transfer_id IS NULL THEN
+    RETURN NULL;
+  END IF;
+
+  SELECT amount, to_amount, fee INTO transfer_amount, transfer_to_amount, transfer_fee
+  FROM transfers WHERE id = checked_transfer_id;
+
+  SELECT
+    COALESCE(SUM(-amount) FILTER (WHERE amount < 0), 0),
+    COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0)
+  INTO debits, credits
+  FROM entries WHERE transfer_id = checked_transfer_id;
+
+  IF debits <> transfer_amount + transfer_fee OR credits <> transfer_to_amount + transfer_fee THEN
+    RAISE EXCEPTION 'entries of transfer % do not balance: debits %, credits %, expected % and % with fee %',
+      checked_transfer_id, debits, credits, transfer_amount, transfer_to_amount, transfer_fee
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- only works before any of them was used, their entries would be orphaned
+DELETE FROM "accounts" WHERE "kind" IN ('funding', 'withdrawal', 'suspense');
+
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_kind_check";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_kind_check" CHECK ("kind" IN ('customer', 'fee_revenue'));
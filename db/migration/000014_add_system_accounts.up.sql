@@ -0,0 +1,67 @@
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_kind_check";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_kind_check" CHECK ("kind" IN ('customer', 'fee_revenue', 'funding', 'withdrawal', 'suspense'));
+
+INSERT INTO "accounts" ("user_id", "account_number", "status", "balance", "currency_code", "kind") VALUES
+  (NULL, 0, 'active', 0, 'USD', 'funding'),
+  (NULL, 0, 'active', 0, 'EUR', 'funding'),
+  (NULL, 0, 'active', 0, 'NGN', 'funding'),
+  (NULL, 0, 'active', 0, 'USD', 'withdrawal'),
+  (NULL, 0, 'active', 0, 'EUR', 'withdrawal'),
+  (NULL, 0, 'active', 0, 'NGN', 'withdrawal'),
+  (NULL, 0, 'active', 0, 'USD', 'suspense'),
+  (NULL, 0, 'active', 0, 'EUR', 'suspense'),
+  (NULL, 0, 'active', 0, 'NGN', 'suspense');
+
+-- the entries of a transfer must net to zero in every currency, cross currency transfers pass through the suspense
+-- accounts to do so. The from account must be debited amount and fee and the to account credited to_amount
+CREATE OR REPLACE FUNCTION check_transfer_entries() RETURNS trigger AS $$
+DECLARE
+  checked_transfer transfers%ROWTYPE;
+  checked_transfer_id bigint;
+  unbalanced_currency varchar;
+  from_total bigint;
+  to_total bigint;
+BEGIN
+  IF TG_TABLE_NAME = 'transfers' THEN
+    checked_transfer_id := NEW.id;
+  ELSIF TG_OP = 'DELETE' THEN
+    checked_transfer_id := OLD.transfer_id;
+  ELSE
+    checked_transfer_id := NEW.transfer_id;
+  END IF;
+
+  IF checked_transfer_id IS NULL THEN
+    RETURN NULL;
+  END IF;
+
+  SELECT * INTO checked_transfer FROM transfers WHERE id = checked_transfer_id;
+
+  SELECT a.currency_code INTO unbalanced_currency
+  FROM entries e
+  JOIN accounts a ON a.id = e.account_id
+  WHERE e.transfer_id = checked_transfer_id
+  GROUP BY a.currency_code
+  HAVING SUM(e.amount) <> 0
+  LIMIT 1;
+
+  IF unbalanced_currency IS NOT NULL THEN
+    RAISE EXCEPTION 'entries of transfer % do not net to zero in %', checked_transfer_id, unbalanced_currency
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  SELECT
+    COALESCE(SUM(amount) FILTER (WHERE account_id = checked_transfer.from_account_id), 0),
+    COALESCE(SUM(amount) FILTER (WHERE account_id = checked_transfer.to_account_id), 0)
+  INTO from_total, to_total
+  FROM entries WHERE transfer_id = checked_transfer_id;
+
+  IF from_total <> -(checked_transfer.amount + checked_transfer.fee) OR to_total <> checked_transfer.to_amount THEN
+    RAISE EXCEPTION 'entries of transfer % do not balance: from %, to %, expected % and %',
+      checked_transfer_id, from_total, to_total, -(checked_transfer.amount + checked_transfer.fee), checked_transfer.to_amount
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,39 @@
+CREATE TABLE "reconciliation_runs" (
+  "id" bigserial PRIMARY KEY,
+  "triggered_by" varchar NOT NULL,
+  "accounts_checked" bigint NOT NULL,
+  "transfers_checked" bigint NOT NULL,
+  "discrepancies" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "reconciliation_discrepancies" (
+  "id" bigserial PRIMARY KEY,
+  "run_id" bigint NOT NULL,
+  "kind" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "transfer_id" bigint,
+  "expected" bigint NOT NULL,
+  "actual" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "reconciliation_runs" ("created_at");
+
+CREATE INDEX ON "reconciliation_discrepancies" ("run_id");
+
+ALTER TABLE "reconciliation_runs" ADD CONSTRAINT "reconciliation_runs_triggered_by_check" CHECK ("triggered_by" IN ('schedule', 'cli'));
+
+ALTER TABLE "reconciliation_discrepancies" ADD CONSTRAINT "reconciliation_discrepancies_kind_check" CHECK ("kind" IN ('account_balance', 'transfer_debit', 'transfer_credit'));
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."kind" IS 'account_balance compares the balance with its entries, transfer_debit and transfer_credit compare a transfer with the entries of its from and to account';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."expected" IS 'the sum of the entries for account_balance, the transfer amount for transfer_debit and transfer_credit';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."actual" IS 'the stored balance for account_balance, the sum of the entries for transfer_debit and transfer_credit';
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("run_id") REFERENCES "reconciliation_runs" ("id");
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
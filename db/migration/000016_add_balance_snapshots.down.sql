@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "balance_snapshots";
+
+DROP INDEX IF EXISTS "entries_account_id_created_at_idx";
@@ -0,0 +1,55 @@
+CREATE TABLE "transfer_batches" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "currency_code" varchar NOT NULL,
+  "format" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "item_count" bigint NOT NULL,
+  "total_amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "completed_at" timestamptz
+);
+
+CREATE TABLE "transfer_batch_items" (
+  "id" bigserial PRIMARY KEY,
+  "batch_id" bigint NOT NULL,
+  "line_number" integer NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "reference" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "failure_reason" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "transfer_batches" ("user_id");
+
+CREATE UNIQUE INDEX ON "transfer_batch_items" ("batch_id", "line_number");
+
+CREATE INDEX ON "transfer_batch_items" ("batch_id", "status");
+
+ALTER TABLE "transfer_batches" ADD CONSTRAINT "transfer_batches_format_check" CHECK ("format" IN ('csv', 'pain001'));
+
+ALTER TABLE "transfer_batches" ADD CONSTRAINT "transfer_batches_status_check" CHECK ("status" IN ('pending', 'processing', 'completed', 'completed_with_errors', 'failed'));
+
+ALTER TABLE "transfer_batch_items" ADD CONSTRAINT "transfer_batch_items_status_check" CHECK ("status" IN ('pending', 'completed', 'failed'));
+
+ALTER TABLE "transfer_batch_items" ADD CONSTRAINT "transfer_batch_items_amount_check" CHECK ("amount" > 0);
+
+COMMENT ON COLUMN "transfer_batches"."format" IS 'the format of the uploaded file, csv or ISO 20022 pain.001';
+
+COMMENT ON COLUMN "transfer_batch_items"."line_number" IS 'the row of a csv file or the position of the transaction in a pain.001 file, counting from 1';
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
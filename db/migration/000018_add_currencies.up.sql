@@ -0,0 +1,21 @@
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "minor_units" integer NOT NULL,
+  "symbol" varchar NOT NULL,
+  "enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "currencies" ADD CONSTRAINT "currencies_minor_units_check" CHECK ("minor_units" BETWEEN 0 AND 4);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 code';
+
+COMMENT ON COLUMN "currencies"."minor_units" IS 'decimal places of the currency, amounts in it are stored as whole numbers of its minor unit';
+
+COMMENT ON COLUMN "currencies"."enabled" IS 'accounts can only be opened and money only moved in enabled currencies, existing accounts in a disabled one are kept';
+
+INSERT INTO "currencies" ("code", "minor_units", "symbol") VALUES
+  ('USD', 2, '$'),
+  ('NGN', 2, '₦'),
+  ('EUR', 2, '€');
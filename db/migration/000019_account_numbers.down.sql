@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "accounts_account_number_idx";
+
+ALTER TABLE "accounts" ALTER COLUMN "account_number" TYPE bigint USING "account_number"::bigint;
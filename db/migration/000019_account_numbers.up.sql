@@ -0,0 +1,30 @@
+ALTER TABLE "accounts" ALTER COLUMN "account_number" TYPE varchar(10) USING lpad("account_number"::text, 10, '0');
+
+-- account numbers used to be random with nothing keeping them apart, so every customer account that shares its
+-- number with an older one gets a new random number before the index is built. Like the other numbers from before
+-- NUBANs it has no check digit, the bank code is only known to the app. Lookups by id are unaffected
+DO $$
+DECLARE
+  duplicate record;
+  new_number varchar(10);
+BEGIN
+  FOR duplicate IN
+    SELECT "id" FROM (
+      SELECT "id", row_number() OVER (PARTITION BY "account_number" ORDER BY "id") AS "position"
+      FROM "accounts" WHERE "kind" = 'customer'
+    ) numbered
+    WHERE "position" > 1
+  LOOP
+    LOOP
+      new_number := lpad(floor(random() * 10000000000)::bigint::text, 10, '0');
+      EXIT WHEN NOT EXISTS (SELECT 1 FROM "accounts" WHERE "account_number" = new_number AND "kind" = 'customer');
+    END LOOP;
+
+    UPDATE "accounts" SET "account_number" = new_number WHERE "id" = duplicate."id";
+  END LOOP;
+END;
+$$;
+
+CREATE UNIQUE INDEX ON "accounts" ("account_number") WHERE "kind" = 'customer';
+
+COMMENT ON COLUMN "accounts"."account_number" IS '10 digit NUBAN with its leading zeros, system accounts all have 0000000000';
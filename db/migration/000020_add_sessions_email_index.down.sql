@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "sessions_email_idx";
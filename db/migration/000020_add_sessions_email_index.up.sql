@@ -0,0 +1 @@
+CREATE INDEX ON "sessions" ("email");
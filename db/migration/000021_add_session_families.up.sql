@@ -0,0 +1,13 @@
+ALTER TABLE "sessions" ADD COLUMN "family_id" uuid;
+
+UPDATE "sessions" SET "family_id" = "id";
+
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions" ADD COLUMN "replaced_by" uuid;
+
+CREATE INDEX ON "sessions" ("family_id");
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'id of the session the user logged in with, every session rotated from it shares it';
+
+COMMENT ON COLUMN "sessions"."replaced_by" IS 'session issued when the refresh token was renewed, a retired refresh token must never be used again';
@@ -0,0 +1,16 @@
+CREATE TABLE "password_resets" (
+  "id" bigserial PRIMARY KEY,
+  "email" varchar NOT NULL,
+  "secret_code_hash" varchar NOT NULL,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '15 minutes')
+);
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("email") REFERENCES "users" ("email");
+
+CREATE UNIQUE INDEX ON "password_resets" ("secret_code_hash");
+
+CREATE INDEX ON "password_resets" ("email");
+
+COMMENT ON COLUMN "password_resets"."secret_code_hash" IS 'sha256 of the secret code emailed to the user, the code itself is never stored';
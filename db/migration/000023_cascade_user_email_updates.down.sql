@@ -0,0 +1,11 @@
+ALTER TABLE "password_resets" DROP CONSTRAINT IF EXISTS "password_resets_email_fkey";
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("email") REFERENCES "users" ("email");
+
+ALTER TABLE "verify_emails" DROP CONSTRAINT IF EXISTS "verify_emails_email_fkey";
+
+ALTER TABLE "verify_emails" ADD FOREIGN KEY ("email") REFERENCES "users" ("email");
+
+ALTER TABLE "sessions" DROP CONSTRAINT IF EXISTS "sessions_email_fkey";
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("email") REFERENCES "users" ("email");
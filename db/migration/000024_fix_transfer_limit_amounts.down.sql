@@ -0,0 +1,10 @@
+UPDATE "transfer_limits"
+SET "per_transaction" = "per_transaction" / 100, "daily" = "daily" / 100, "monthly" = "monthly" / 100
+WHERE ("tier", "currency_code", "per_transaction", "daily", "monthly") IN (VALUES
+  ('standard', 'USD', 500000::bigint, 1000000::bigint, 5000000::bigint),
+  ('standard', 'EUR', 500000, 1000000, 5000000),
+  ('standard', 'NGN', 200000000, 500000000, 2000000000),
+  ('premium', 'USD', 5000000, 10000000, 50000000),
+  ('premium', 'EUR', 5000000, 10000000, 50000000),
+  ('premium', 'NGN', 2000000000, 5000000000, 20000000000)
+);
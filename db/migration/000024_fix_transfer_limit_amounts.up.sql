@@ -0,0 +1,15 @@
+-- 000012 seeded the limits in whole units but they are stored in minor units like every other amount, so standard
+-- USD and EUR users could only send 50.00 at a time. The intended limits are, per transfer, day and month:
+--   standard USD and EUR 5,000.00, 10,000.00 and 50,000.00, premium ten times that
+--   standard NGN 2,000,000.00, 5,000,000.00 and 20,000,000.00, premium ten times that
+-- Only rows that still hold the seeded values are changed, limits an admin has set since are kept
+UPDATE "transfer_limits"
+SET "per_transaction" = "per_transaction" * 100, "daily" = "daily" * 100, "monthly" = "monthly" * 100
+WHERE ("tier", "currency_code", "per_transaction", "daily", "monthly") IN (VALUES
+  ('standard', 'USD', 5000::bigint, 10000::bigint, 50000::bigint),
+  ('standard', 'EUR', 5000, 10000, 50000),
+  ('standard', 'NGN', 2000000, 5000000, 20000000),
+  ('premium', 'USD', 50000, 100000, 500000),
+  ('premium', 'EUR', 50000, 100000, 500000),
+  ('premium', 'NGN', 20000000, 50000000, 200000000)
+);
@@ -0,0 +1,15 @@
+-- the opening entries are the only funding account entries that don't belong to a transfer
+WITH "removed" AS (
+  DELETE FROM "entries" e
+  USING "accounts" a
+  WHERE e."account_id" = a."id" AND a."kind" = 'funding' AND e."transfer_id" IS NULL
+  RETURNING e."account_id", e."amount"
+)
+UPDATE "accounts" a
+SET "balance" = a."balance" - r."total"
+FROM (
+  SELECT "account_id", SUM("amount")::bigint AS "total"
+  FROM "removed"
+  GROUP BY "account_id"
+) r
+WHERE a."id" = r."account_id";
@@ -0,0 +1,28 @@
+-- balances written before the ledger, by deposits straight to the balance or cross currency transfers made before the
+-- suspense accounts, have no system account on the other side, so the trial balance of their currency doesn't come to
+-- zero. This is the cut-over: each currency gets one opening entry on its funding account, as if the money had been
+-- deposited through the ledger, and from here on every currency balances
+WITH "openings" AS (
+  SELECT f."id" AS "account_id", (-t."total")::bigint AS "amount"
+  FROM (
+    SELECT "currency_code", SUM("balance") AS "total"
+    FROM "accounts"
+    GROUP BY "currency_code"
+  ) t
+  JOIN LATERAL (
+    SELECT "id" FROM "accounts"
+    WHERE "kind" = 'funding' AND "currency_code" = t."currency_code" AND "user_id" IS NULL
+    ORDER BY "id"
+    LIMIT 1
+  ) f ON true
+  WHERE t."total" <> 0
+), "posted" AS (
+  INSERT INTO "entries" ("account_id", "amount", "debit_credit")
+  SELECT "account_id", "amount", CASE WHEN "amount" < 0 THEN 'debit' ELSE 'credit' END
+  FROM "openings"
+  RETURNING "account_id", "amount"
+)
+UPDATE "accounts" a
+SET "balance" = a."balance" + p."amount"
+FROM "posted" p
+WHERE a."id" = p."account_id";
@@ -9,10 +9,30 @@ INSERT INTO accounts (
   $1, $2, $3, $4, $5
 ) RETURNING *;
 
+-- name: CreateSystemAccounts :exec
+INSERT INTO accounts (
+  user_id,
+  account_number,
+  status,
+  balance,
+  currency_code,
+  kind
+) VALUES
+  (NULL, '0000000000', 'active', 0, $1, 'funding'),
+  (NULL, '0000000000', 'active', 0, $1, 'withdrawal'),
+  (NULL, '0000000000', 'active', 0, $1, 'suspense'),
+  (NULL, '0000000000', 'active', 0, $1, 'fee_revenue')
+ON CONFLICT DO NOTHING;
+
 -- name: GetAccount :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE account_number = $1 AND kind = 'customer'
+LIMIT 1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1 
@@ -40,3 +60,52 @@ RETURNING *;
 -- name: DeleteAccount :exec
 DELETE FROM accounts 
 WHERE id = $1;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: GetSystemAccount :one
+SELECT * FROM accounts
+WHERE kind = $1 AND currency_code = $2 AND user_id IS NULL
+LIMIT 1;
+
+-- name: GetTrialBalance :many
+SELECT
+  currency_code,
+  kind,
+  COUNT(*)::bigint AS accounts,
+  COALESCE(SUM(balance), 0)::bigint AS balance
+FROM accounts
+GROUP BY currency_code, kind
+ORDER BY currency_code, kind;
+
+-- name: RewindAccountBalance :one
+SELECT (CASE
+  WHEN a.created_at >= sqlc.arg(at) THEN 0
+  ELSE a.balance - COALESCE(SUM(e.amount), 0)
+END)::bigint AS balance
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= sqlc.arg(at)
+WHERE a.id = sqlc.arg(account_id)
+GROUP BY a.id;
+
+-- name: ListStatementRecipients :many
+SELECT a.id AS account_id, u.account_name, u.email
+FROM accounts a
+JOIN users u ON u.id = a.user_id
+WHERE a.kind = 'customer' AND a.status IN ('active', 'frozen')
+ORDER BY a.id;
+
+-- name: ListAccountsByIDs :many
+SELECT * FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+ORDER BY id;
@@ -0,0 +1,18 @@
+-- name: CreateBalanceSnapshots :execrows
+INSERT INTO balance_snapshots (
+  account_id,
+  snapshot_at,
+  balance
+)
+SELECT a.id, sqlc.arg(snapshot_at), a.balance - COALESCE(SUM(e.amount), 0)
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= sqlc.arg(snapshot_at)
+WHERE a.created_at < sqlc.arg(snapshot_at)
+GROUP BY a.id
+ON CONFLICT (account_id, snapshot_at) DO NOTHING;
+
+-- name: GetLatestBalanceSnapshot :one
+SELECT * FROM balance_snapshots
+WHERE account_id = $1 AND snapshot_at <= sqlc.arg(at)
+ORDER BY snapshot_at DESC
+LIMIT 1;
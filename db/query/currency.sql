@@ -0,0 +1,27 @@
+-- name: CreateCurrency :one
+INSERT INTO currencies (
+  code,
+  minor_units,
+  symbol,
+  enabled
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetCurrency :one
+SELECT * FROM currencies
+WHERE code = $1 LIMIT 1;
+
+-- name: ListCurrencies :many
+SELECT * FROM currencies
+ORDER BY code;
+
+-- name: UpdateCurrency :one
+UPDATE currencies
+SET
+  symbol = COALESCE(sqlc.narg(symbol), symbol),
+  enabled = COALESCE(sqlc.narg(enabled), enabled),
+  updated_at = now()
+WHERE
+  code = sqlc.arg(code)
+RETURNING *;
@@ -2,9 +2,10 @@
 INSERT INTO entries (
   account_id,
   amount,
-  debit_credit
+  debit_credit,
+  transfer_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -26,3 +27,39 @@ RETURNING *;
 -- name: DeleteEntry :exec
 DELETE FROM entries 
 WHERE id = $1;
+
+-- name: ListAccountEntries :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND id > sqlc.arg(cursor)
+  AND (sqlc.narg(debit_credit)::varchar IS NULL OR debit_credit = sqlc.narg(debit_credit))
+  AND (sqlc.narg(from_date)::timestamptz IS NULL OR created_at >= sqlc.narg(from_date))
+  AND (sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR ABS(amount) >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR ABS(amount) <= sqlc.narg(max_amount))
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetAccountEntryTotal :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1
+  AND created_at >= sqlc.arg(since)
+  AND created_at < sqlc.arg(until);
+
+-- name: ListStatementEntries :many
+SELECT
+  e.id,
+  e.amount,
+  e.debit_credit,
+  e.created_at,
+  e.transfer_id,
+  c.account_number AS counterparty_account_number,
+  c.kind AS counterparty_kind
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+LEFT JOIN accounts c ON c.id = (CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END)
+WHERE e.account_id = $1
+  AND e.created_at >= sqlc.arg(from_date)
+  AND e.created_at < sqlc.arg(to_date)
+ORDER BY e.created_at, e.id;
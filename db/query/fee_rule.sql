@@ -0,0 +1,24 @@
+-- name: CreateFeeRule :one
+INSERT INTO fee_rules (
+  currency_code,
+  min_amount,
+  flat_fee,
+  percentage_bps
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetTransferFeeRule :one
+SELECT * FROM fee_rules
+WHERE currency_code = $1 AND min_amount <= sqlc.arg(amount)
+ORDER BY min_amount DESC
+LIMIT 1;
+
+-- name: ListFeeRules :many
+SELECT * FROM fee_rules
+ORDER BY currency_code, min_amount;
+
+-- name: DeleteFeeRule :one
+DELETE FROM fee_rules
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,61 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  account_id,
+  to_account_id,
+  amount,
+  currency_code,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListAccountHolds :many
+SELECT * FROM holds
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: GetAccountHeldAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS held_amount FROM holds
+WHERE account_id = $1
+  AND status = 'active'
+  AND expires_at > now();
+
+-- name: ListAccountHeldAmounts :many
+-- accounts with nothing on hold have no row
+SELECT account_id, SUM(amount)::bigint AS held_amount FROM holds
+WHERE account_id = ANY(sqlc.arg(ids)::bigint[])
+  AND status = 'active'
+  AND expires_at > now()
+GROUP BY account_id;
+
+-- name: UpdateHold :one
+UPDATE holds
+SET
+  status = sqlc.arg(status),
+  captured_amount = COALESCE(sqlc.narg(captured_amount), captured_amount),
+  transfer_id = COALESCE(sqlc.narg(transfer_id), transfer_id),
+  updated_at = now()
+WHERE
+  id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ExpireHold :one
+UPDATE holds
+SET
+  status = 'expired',
+  updated_at = now()
+WHERE id = $1
+  AND status = 'active'
+  AND expires_at <= now()
+RETURNING *;
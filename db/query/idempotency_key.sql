@@ -0,0 +1,26 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+  user_id,
+  key,
+  request_path,
+  request_hash,
+  expired_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND key = $2 LIMIT 1;
+
+-- name: UpdateIdempotencyKeyResponse :one
+UPDATE idempotency_keys
+SET
+  response_code = $2,
+  response_body = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE id = $1;
@@ -0,0 +1,18 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+  email,
+  secret_code_hash
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: UsePasswordReset :one
+UPDATE password_resets
+SET is_used = TRUE
+WHERE secret_code_hash = $1 AND is_used = FALSE AND expired_at > now()
+RETURNING *;
+
+-- name: InvalidatePasswordResets :exec
+UPDATE password_resets
+SET is_used = TRUE
+WHERE email = $1 AND is_used = FALSE;
@@ -0,0 +1,72 @@
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (
+  triggered_by,
+  accounts_checked,
+  transfers_checked,
+  discrepancies
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetReconciliationRun :one
+SELECT * FROM reconciliation_runs
+WHERE id = $1 LIMIT 1;
+
+-- name: ListReconciliationRuns :many
+SELECT * FROM reconciliation_runs
+ORDER BY id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: CreateReconciliationDiscrepancy :one
+INSERT INTO reconciliation_discrepancies (
+  run_id,
+  kind,
+  account_id,
+  transfer_id,
+  expected,
+  actual
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListReconciliationDiscrepancies :many
+SELECT * FROM reconciliation_discrepancies
+WHERE run_id = $1
+ORDER BY id;
+
+-- name: CountAccounts :one
+SELECT COUNT(*) FROM accounts;
+
+-- name: CountTransfers :one
+-- counts the transfers ListUnbalancedTransfers checks, the ones with linked entries
+SELECT COUNT(*) FROM transfers t
+WHERE EXISTS (SELECT 1 FROM entries e WHERE e.transfer_id = t.id);
+
+-- name: ListBalanceMismatches :many
+SELECT a.id, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+GROUP BY a.id
+HAVING a.balance <> COALESCE(SUM(e.amount), 0)
+ORDER BY a.id;
+
+-- name: ListUnbalancedTransfers :many
+-- transfers made before entries were linked to them have no linked entries and are skipped, telling their entries
+-- apart from others for the same accounts and amount isn't reliable enough to backfill transfer_id. Every later
+-- transfer has linked entries since the transfers_entries_balance trigger rejects one without them
+SELECT
+  t.id,
+  t.from_account_id,
+  t.to_account_id,
+  (t.amount + t.fee)::bigint AS debit,
+  COALESCE(-SUM(e.amount) FILTER (WHERE e.account_id = t.from_account_id), 0)::bigint AS debit_entries,
+  t.to_amount AS credit,
+  COALESCE(SUM(e.amount) FILTER (WHERE e.account_id = t.to_account_id), 0)::bigint AS credit_entries
+FROM transfers t
+JOIN entries e ON e.transfer_id = t.id
+GROUP BY t.id
+HAVING
+  t.amount + t.fee <> COALESCE(-SUM(e.amount) FILTER (WHERE e.account_id = t.from_account_id), 0)
+  OR t.to_amount <> COALESCE(SUM(e.amount) FILTER (WHERE e.account_id = t.to_account_id), 0)
+ORDER BY t.id;
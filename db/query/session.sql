@@ -1,6 +1,7 @@
 -- name: CreateSession :one
 INSERT INTO sessions (
     id,
+    family_id,
     email,
     refresh_token,
     user_agent,
@@ -8,9 +9,47 @@ INSERT INTO sessions (
     is_blocked,
     expires_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
 -- name: GetSession :one
 SELECT * FROM sessions 
 WHERE id = $1 LIMIT 1;
+
+-- name: GetSessionAuth :one
+SELECT sessions.id, sessions.email, sessions.is_blocked, sessions.expires_at, users.password_changed_at
+FROM sessions
+JOIN users ON users.email = sessions.email
+WHERE sessions.id = $1 LIMIT 1;
+
+-- name: ListActiveSessions :many
+SELECT * FROM sessions
+WHERE email = $1 AND is_blocked = false AND replaced_by IS NULL AND expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1
+RETURNING *;
+
+-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE email = $1 AND is_blocked = false AND expires_at > now();
+
+-- name: RetireSession :one
+UPDATE sessions
+SET replaced_by = $2
+WHERE id = $1 AND replaced_by IS NULL
+RETURNING *;
+
+-- name: BlockSessionFamily :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1 AND is_blocked = false;
+
+-- name: BlockOtherUserSessions :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE email = $1 AND id <> $2 AND is_blocked = false AND expires_at > now();
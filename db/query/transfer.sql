@@ -2,15 +2,24 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  to_amount,
+  exchange_rate,
+  reversal_of,
+  fee
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
 ORDER BY id
@@ -23,6 +32,37 @@ SET amount = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateTransferReversal :one
+UPDATE transfers
+SET
+  status = $2,
+  reversed_amount = $3
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteTransfer :exec
 DELETE FROM transfers 
 WHERE id = $1;
+
+-- name: ListAccountTransfers :many
+SELECT * FROM transfers
+WHERE (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id))
+  AND id > sqlc.arg(cursor)
+  AND (sqlc.narg(direction)::varchar IS NULL
+    OR (sqlc.narg(direction) = 'debit' AND from_account_id = sqlc.arg(account_id))
+    OR (sqlc.narg(direction) = 'credit' AND to_account_id = sqlc.arg(account_id)))
+  AND (sqlc.narg(from_date)::timestamptz IS NULL OR created_at >= sqlc.narg(from_date))
+  AND (sqlc.narg(to_date)::timestamptz IS NULL OR created_at < sqlc.narg(to_date))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: GetUserTransferTotal :one
+SELECT COALESCE(SUM(t.amount), 0)::bigint AS total
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.user_id = $1
+  AND a.currency_code = $2
+  AND t.reversal_of IS NULL
+  AND t.created_at >= sqlc.arg(since);
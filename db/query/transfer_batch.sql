@@ -0,0 +1,81 @@
+-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+  user_id,
+  from_account_id,
+  currency_code,
+  format,
+  item_count,
+  total_amount
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetTransferBatch :one
+SELECT * FROM transfer_batches
+WHERE id = $1 LIMIT 1;
+
+-- name: ListTransferBatches :many
+SELECT * FROM transfer_batches
+WHERE user_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: UpdateTransferBatch :one
+UPDATE transfer_batches
+SET
+  status = COALESCE(sqlc.narg(status), status),
+  completed_at = COALESCE(sqlc.narg(completed_at), completed_at),
+  updated_at = now()
+WHERE
+  id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateTransferBatchItem :one
+INSERT INTO transfer_batch_items (
+  batch_id,
+  line_number,
+  to_account_id,
+  amount,
+  reference
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetTransferBatchItemForUpdate :one
+SELECT * FROM transfer_batch_items
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPendingTransferBatchItemIDs :many
+SELECT id FROM transfer_batch_items
+WHERE batch_id = $1 AND status = 'pending'
+ORDER BY line_number;
+
+-- name: ListTransferBatchItems :many
+SELECT * FROM transfer_batch_items
+WHERE batch_id = sqlc.arg(batch_id)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+ORDER BY line_number
+LIMIT sqlc.arg(page_size)
+OFFSET sqlc.arg(page_offset);
+
+-- name: UpdateTransferBatchItem :one
+UPDATE transfer_batch_items
+SET
+  status = $2,
+  transfer_id = $3,
+  failure_reason = $4,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetTransferBatchSummary :one
+SELECT
+  COUNT(*) FILTER (WHERE status = 'pending') AS pending_items,
+  COUNT(*) FILTER (WHERE status = 'completed') AS completed_items,
+  COUNT(*) FILTER (WHERE status = 'failed') AS failed_items,
+  COALESCE(SUM(amount) FILTER (WHERE status = 'completed'), 0)::bigint AS completed_amount,
+  COALESCE(SUM(amount) FILTER (WHERE status = 'failed'), 0)::bigint AS failed_amount
+FROM transfer_batch_items
+WHERE batch_id = $1;
@@ -0,0 +1,22 @@
+-- name: GetTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE tier = $1 AND currency_code = $2 LIMIT 1;
+
+-- name: ListTransferLimits :many
+SELECT * FROM transfer_limits
+WHERE tier = $1
+ORDER BY currency_code;
+
+-- name: UpsertTransferLimit :one
+INSERT INTO transfer_limits (
+  tier,
+  currency_code,
+  per_transaction,
+  daily,
+  monthly
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (tier, currency_code) DO UPDATE
+SET per_transaction = EXCLUDED.per_transaction, daily = EXCLUDED.daily, monthly = EXCLUDED.monthly
+RETURNING *;
@@ -52,3 +52,15 @@ RETURNING *;
 -- name: DeleteUser :exec
 DELETE FROM users 
 WHERE id = $1;
+
+-- name: UpdateUserTier :one
+UPDATE users
+SET tier = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserEmail :one
+UPDATE users
+SET email = $2, is_email_verified = false
+WHERE id = $1
+RETURNING *;
@@ -16,4 +16,7 @@ RETURNING *;
 SELECT * FROM verify_emails
 WHERE id = $1 LIMIT 1;
 
-
+-- name: InvalidateVerifyEmails :exec
+UPDATE verify_emails
+SET is_used = TRUE
+WHERE email = $1 AND is_used = FALSE;
@@ -7,13 +7,16 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, user_id, account_number, status, balance, currency_code, created_at
+RETURNING id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind
 `
 
 type AddAccountBalanceParams struct {
@@ -32,6 +35,8 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.CurrencyCode,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
 	)
 	return i, err
 }
@@ -45,15 +50,15 @@ INSERT INTO accounts (
   currency_code
 ) VALUES (
   $1, $2, $3, $4, $5
-) RETURNING id, user_id, account_number, status, balance, currency_code, created_at
+) RETURNING id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind
 `
 
 type CreateAccountParams struct {
-	UserID        int64  `json:"user_id"`
-	AccountNumber int64  `json:"account_number"`
-	Status        string `json:"status"`
-	Balance       int64  `json:"balance"`
-	CurrencyCode  string `json:"currency_code"`
+	UserID        pgtype.Int8 `json:"user_id"`
+	AccountNumber string      `json:"account_number"`
+	Status        string      `json:"status"`
+	Balance       int64       `json:"balance"`
+	CurrencyCode  string      `json:"currency_code"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
@@ -73,10 +78,33 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.CurrencyCode,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
 	)
 	return i, err
 }
 
+const createSystemAccounts = `-- name: CreateSystemAccounts :exec
+INSERT INTO accounts (
+  user_id,
+  account_number,
+  status,
+  balance,
+  currency_code,
+  kind
+) VALUES
+  (NULL, '0000000000', 'active', 0, $1, 'funding'),
+  (NULL, '0000000000', 'active', 0, $1, 'withdrawal'),
+  (NULL, '0000000000', 'active', 0, $1, 'suspense'),
+  (NULL, '0000000000', 'active', 0, $1, 'fee_revenue')
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) CreateSystemAccounts(ctx context.Context, currencyCode string) error {
+	_, err := q.db.Exec(ctx, createSystemAccounts, currencyCode)
+	return err
+}
+
 const deleteAccount = `-- name: DeleteAccount :exec
 DELETE FROM accounts 
 WHERE id = $1
@@ -88,7 +116,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, user_id, account_number, status, balance, currency_code, created_at FROM accounts
+SELECT id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -103,12 +131,37 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.CurrencyCode,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind FROM accounts
+WHERE account_number = $1 AND kind = 'customer'
+LIMIT 1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountByNumber, accountNumber)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Status,
+		&i.Balance,
+		&i.CurrencyCode,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, user_id, account_number, status, balance, currency_code, created_at FROM accounts
+SELECT id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind FROM accounts
 WHERE id = $1 LIMIT 1 
 FOR NO KEY UPDATE
 `
@@ -124,12 +177,85 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.CurrencyCode,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const getSystemAccount = `-- name: GetSystemAccount :one
+SELECT id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind FROM accounts
+WHERE kind = $1 AND currency_code = $2 AND user_id IS NULL
+LIMIT 1
+`
+
+type GetSystemAccountParams struct {
+	Kind         string `json:"kind"`
+	CurrencyCode string `json:"currency_code"`
+}
+
+func (q *Queries) GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error) {
+	row := q.db.QueryRow(ctx, getSystemAccount, arg.Kind, arg.CurrencyCode)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Status,
+		&i.Balance,
+		&i.CurrencyCode,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
 	)
 	return i, err
 }
 
+const getTrialBalance = `-- name: GetTrialBalance :many
+SELECT
+  currency_code,
+  kind,
+  COUNT(*)::bigint AS accounts,
+  COALESCE(SUM(balance), 0)::bigint AS balance
+FROM accounts
+GROUP BY currency_code, kind
+ORDER BY currency_code, kind
+`
+
+type GetTrialBalanceRow struct {
+	CurrencyCode string `json:"currency_code"`
+	Kind         string `json:"kind"`
+	Accounts     int64  `json:"accounts"`
+	Balance      int64  `json:"balance"`
+}
+
+func (q *Queries) GetTrialBalance(ctx context.Context) ([]GetTrialBalanceRow, error) {
+	rows, err := q.db.Query(ctx, getTrialBalance)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTrialBalanceRow{}
+	for rows.Next() {
+		var i GetTrialBalanceRow
+		if err := rows.Scan(
+			&i.CurrencyCode,
+			&i.Kind,
+			&i.Accounts,
+			&i.Balance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, user_id, account_number, status, balance, currency_code, created_at FROM accounts
+SELECT id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind FROM accounts
 WHERE user_id = $1
 ORDER BY id
 LIMIT $2
@@ -137,9 +263,9 @@ OFFSET $3
 `
 
 type ListAccountsParams struct {
-	UserID int64 `json:"user_id"`
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	UserID pgtype.Int8 `json:"user_id"`
+	Limit  int32       `json:"limit"`
+	Offset int32       `json:"offset"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
@@ -159,6 +285,82 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.CurrencyCode,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.Kind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByIDs = `-- name: ListAccountsByIDs :many
+SELECT id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind FROM accounts
+WHERE id = ANY($1::bigint[])
+ORDER BY id
+`
+
+func (q *Queries) ListAccountsByIDs(ctx context.Context, ids []int64) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.AccountNumber,
+			&i.Status,
+			&i.Balance,
+			&i.CurrencyCode,
+			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.Kind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementRecipients = `-- name: ListStatementRecipients :many
+SELECT a.id AS account_id, u.account_name, u.email
+FROM accounts a
+JOIN users u ON u.id = a.user_id
+WHERE a.kind = 'customer' AND a.status IN ('active', 'frozen')
+ORDER BY a.id
+`
+
+type ListStatementRecipientsRow struct {
+	AccountID   int64  `json:"account_id"`
+	AccountName string `json:"account_name"`
+	Email       string `json:"email"`
+}
+
+func (q *Queries) ListStatementRecipients(ctx context.Context) ([]ListStatementRecipientsRow, error) {
+	rows, err := q.db.Query(ctx, listStatementRecipients)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementRecipientsRow{}
+	for rows.Next() {
+		var i ListStatementRecipientsRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.AccountName,
+			&i.Email,
 		); err != nil {
 			return nil, err
 		}
@@ -170,11 +372,34 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const rewindAccountBalance = `-- name: RewindAccountBalance :one
+SELECT (CASE
+  WHEN a.created_at >= $1 THEN 0
+  ELSE a.balance - COALESCE(SUM(e.amount), 0)
+END)::bigint AS balance
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= $1
+WHERE a.id = $2
+GROUP BY a.id
+`
+
+type RewindAccountBalanceParams struct {
+	At        time.Time `json:"at"`
+	AccountID int64     `json:"account_id"`
+}
+
+func (q *Queries) RewindAccountBalance(ctx context.Context, arg RewindAccountBalanceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, rewindAccountBalance, arg.At, arg.AccountID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, user_id, account_number, status, balance, currency_code, created_at
+RETURNING id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind
 `
 
 type UpdateAccountParams struct {
@@ -193,6 +418,66 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.CurrencyCode,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const updateAccountOverdraftLimit = `-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	ID             int64 `json:"id"`
+	OverdraftLimit int64 `json:"overdraft_limit"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountOverdraftLimit, arg.ID, arg.OverdraftLimit)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Status,
+		&i.Balance,
+		&i.CurrencyCode,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING id, user_id, account_number, status, balance, currency_code, created_at, overdraft_limit, kind
+`
+
+type UpdateAccountStatusParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountStatus, arg.ID, arg.Status)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.AccountNumber,
+		&i.Status,
+		&i.Balance,
+		&i.CurrencyCode,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
 	)
 	return i, err
 }
@@ -5,20 +5,25 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kelvinator07/golang-bank-microservices/util"
 	"github.com/stretchr/testify/assert"
 )
 
 // create users, then use user id to create aacount
 func createRandomAccount(t *testing.T) Account {
+	return createTestAccount(t, util.RandomStatus(), util.RandomCurrency())
+}
+
+func createTestAccount(t *testing.T, status string, currencyCode string) Account {
 	user := createRandomUser(t)
 
 	arg := CreateAccountParams{
-		UserID:        user.ID,
+		UserID:        pgtype.Int8{Int64: user.ID, Valid: true},
 		AccountNumber: util.RandomAccountNumber(),
-		Status:        util.RandomStatus(),
-		Balance:       util.RandomMoney(),
-		CurrencyCode:  util.RandomCurrency(),
+		Status:        status,
+		Balance:       util.RandomInt(100, 1000), // enough for the concurrent transfer tests, transfers can't overdraw
+		CurrencyCode:  currencyCode,
 	}
 
 	account, err := testStore.CreateAccount(context.Background(), arg)
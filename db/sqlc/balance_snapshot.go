@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// BalanceSnapshotTime returns the snapshot boundary for t, the start of its UTC day
+func BalanceSnapshotTime(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+type GetAccountBalanceAtParams struct {
+	AccountID int64     `json:"account_id"`
+	At        time.Time `json:"at"`
+}
+
+// GetAccountBalanceAt returns the balance of an account at a point in time, counting the entries created before it.
+// It starts from the latest snapshot taken at or before then and adds the entries since, without a snapshot
+// it walks the current balance back instead. An account that didn't exist yet has a zero balance
+func (store *SQLStore) GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error) {
+	snapshot, err := store.GetLatestBalanceSnapshot(ctx, GetLatestBalanceSnapshotParams{
+		AccountID: arg.AccountID,
+		At:        arg.At,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return store.RewindAccountBalance(ctx, RewindAccountBalanceParams{
+				At:        arg.At,
+				AccountID: arg.AccountID,
+			})
+		}
+		return 0, err
+	}
+
+	total, err := store.GetAccountEntryTotal(ctx, GetAccountEntryTotalParams{
+		AccountID: arg.AccountID,
+		Since:     snapshot.SnapshotAt,
+		Until:     arg.At,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return snapshot.Balance + total, nil
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+// RegistryCurrency converts the row to the currency util's registry holds
+func (currency Currency) RegistryCurrency() util.Currency {
+	return util.Currency{
+		Code:       currency.Code,
+		MinorUnits: int(currency.MinorUnits),
+		Symbol:     currency.Symbol,
+		Enabled:    currency.Enabled,
+	}
+}
+
+// LoadCurrencies replaces util's currency registry with the currencies table
+func LoadCurrencies(ctx context.Context, q Querier) error {
+	currencies, err := q.ListCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	registry := make([]util.Currency, 0, len(currencies))
+	for _, currency := range currencies {
+		registry = append(registry, currency.RegistryCurrency())
+	}
+
+	util.SetCurrencies(registry)
+	return nil
+}
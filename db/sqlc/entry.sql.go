@@ -7,26 +7,36 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
   amount,
-  debit_credit
+  debit_credit,
+  transfer_id
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, account_id, amount, debit_credit, created_at
+  $1, $2, $3, $4
+) RETURNING id, account_id, amount, debit_credit, created_at, transfer_id
 `
 
 type CreateEntryParams struct {
-	AccountID   int64  `json:"account_id"`
-	Amount      int64  `json:"amount"`
-	DebitCredit string `json:"debit_credit"`
+	AccountID   int64       `json:"account_id"`
+	Amount      int64       `json:"amount"`
+	DebitCredit string      `json:"debit_credit"`
+	TransferID  pgtype.Int8 `json:"transfer_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRow(ctx, createEntry, arg.AccountID, arg.Amount, arg.DebitCredit)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.DebitCredit,
+		arg.TransferID,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +44,7 @@ func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry
 		&i.Amount,
 		&i.DebitCredit,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
@@ -48,8 +59,29 @@ func (q *Queries) DeleteEntry(ctx context.Context, id int64) error {
 	return err
 }
 
+const getAccountEntryTotal = `-- name: GetAccountEntryTotal :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+`
+
+type GetAccountEntryTotalParams struct {
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+}
+
+func (q *Queries) GetAccountEntryTotal(ctx context.Context, arg GetAccountEntryTotalParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getAccountEntryTotal, arg.AccountID, arg.Since, arg.Until)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, debit_credit, created_at FROM entries
+SELECT id, account_id, amount, debit_credit, created_at, transfer_id FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -62,12 +94,73 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.Amount,
 		&i.DebitCredit,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
 
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, debit_credit, created_at, transfer_id FROM entries
+WHERE account_id = $1
+  AND id > $2
+  AND ($3::varchar IS NULL OR debit_credit = $3)
+  AND ($4::timestamptz IS NULL OR created_at >= $4)
+  AND ($5::timestamptz IS NULL OR created_at < $5)
+  AND ($6::bigint IS NULL OR ABS(amount) >= $6)
+  AND ($7::bigint IS NULL OR ABS(amount) <= $7)
+ORDER BY id
+LIMIT $8
+`
+
+type ListAccountEntriesParams struct {
+	AccountID   int64              `json:"account_id"`
+	Cursor      int64              `json:"cursor"`
+	DebitCredit pgtype.Text        `json:"debit_credit"`
+	FromDate    pgtype.Timestamptz `json:"from_date"`
+	ToDate      pgtype.Timestamptz `json:"to_date"`
+	MinAmount   pgtype.Int8        `json:"min_amount"`
+	MaxAmount   pgtype.Int8        `json:"max_amount"`
+	PageSize    int32              `json:"page_size"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.Cursor,
+		arg.DebitCredit,
+		arg.FromDate,
+		arg.ToDate,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.DebitCredit,
+			&i.CreatedAt,
+			&i.TransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, debit_credit, created_at FROM entries
+SELECT id, account_id, amount, debit_credit, created_at, transfer_id FROM entries
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -93,6 +186,69 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.Amount,
 			&i.DebitCredit,
 			&i.CreatedAt,
+			&i.TransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT
+  e.id,
+  e.amount,
+  e.debit_credit,
+  e.created_at,
+  e.transfer_id,
+  c.account_number AS counterparty_account_number,
+  c.kind AS counterparty_kind
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+LEFT JOIN accounts c ON c.id = (CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END)
+WHERE e.account_id = $1
+  AND e.created_at >= $2
+  AND e.created_at < $3
+ORDER BY e.created_at, e.id
+`
+
+type ListStatementEntriesParams struct {
+	AccountID int64     `json:"account_id"`
+	FromDate  time.Time `json:"from_date"`
+	ToDate    time.Time `json:"to_date"`
+}
+
+type ListStatementEntriesRow struct {
+	ID                        int64       `json:"id"`
+	Amount                    int64       `json:"amount"`
+	DebitCredit               string      `json:"debit_credit"`
+	CreatedAt                 time.Time   `json:"created_at"`
+	TransferID                pgtype.Int8 `json:"transfer_id"`
+	CounterpartyAccountNumber pgtype.Text `json:"counterparty_account_number"`
+	CounterpartyKind          pgtype.Text `json:"counterparty_kind"`
+}
+
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listStatementEntries, arg.AccountID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.DebitCredit,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.CounterpartyAccountNumber,
+			&i.CounterpartyKind,
 		); err != nil {
 			return nil, err
 		}
@@ -108,7 +264,7 @@ const updateEntry = `-- name: UpdateEntry :one
 UPDATE entries
 SET amount = $2
 WHERE id = $1
-RETURNING id, account_id, amount, debit_credit, created_at
+RETURNING id, account_id, amount, debit_credit, created_at, transfer_id
 `
 
 type UpdateEntryParams struct {
@@ -125,6 +281,7 @@ func (q *Queries) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry
 		&i.Amount,
 		&i.DebitCredit,
 		&i.CreatedAt,
+		&i.TransferID,
 	)
 	return i, err
 }
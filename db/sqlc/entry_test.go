@@ -1,11 +1,32 @@
 package db
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/kelvinator07/golang-bank-microservices/util"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateEntry(t *testing.T) {
 	assert.True(t, true)
 }
+
+func TestListStatementEntries(t *testing.T) {
+	testAccount := createTestAccount(t, AccountStatusActive, util.USD)
+
+	deposit, err := testStore.DepositTx(context.Background(), LedgerTxParams{AccountID: testAccount.ID, Amount: 10})
+	assert.NoError(t, err)
+
+	entries, err := testStore.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID: testAccount.ID,
+		FromDate:  testAccount.CreatedAt,
+		ToDate:    deposit.ToEntry.CreatedAt.Add(time.Microsecond),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, deposit.ToEntry.ID, entries[0].ID)
+	assert.Equal(t, deposit.Transfer.ID, entries[0].TransferID.Int64)
+	assert.Equal(t, AccountKindFunding, entries[0].CounterpartyKind.String)
+}
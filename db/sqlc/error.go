@@ -12,8 +12,33 @@ const (
 	UniqueViolation     = "23505"
 )
 
+// AccountNumberIndex is the unique index that keeps customer account numbers apart
+const AccountNumberIndex = "accounts_account_number_idx"
+
 var ErrRecordNotFound = pgx.ErrNoRows
 
+var (
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	ErrAccountNotActive        = errors.New("account is not active")
+	ErrAccountBalanceNotZero   = errors.New("account balance must be zero")
+	ErrCurrencyMismatch        = errors.New("currency mismatch")
+	ErrCurrencyDisabled        = errors.New("currency is disabled")
+	ErrAmountTooSmall          = errors.New("amount is too small to convert")
+	ErrAmountTooLarge          = errors.New("amount is too large")
+	ErrScheduledTransferNotDue = errors.New("scheduled transfer is not due")
+	ErrTransferAlreadyReversed = errors.New("transfer is already reversed")
+	ErrTransferNotReversible   = errors.New("transfer can't be reversed")
+	ErrReversalAmountTooLarge  = errors.New("reversal amount is more than what is left to reverse")
+	ErrInsufficientFunds       = errors.New("insufficient funds")
+	ErrHoldNotActive           = errors.New("hold is not active")
+	ErrCaptureAmountTooLarge   = errors.New("capture amount is more than the hold")
+	ErrTransferLimitExceeded   = errors.New("transfer limit exceeded")
+	ErrNotCustomerAccount      = errors.New("account is not a customer account")
+	ErrSameAccount             = errors.New("can't transfer to the account the money comes from")
+	ErrSessionRetired          = errors.New("session was already renewed")
+	ErrPasswordResetInvalid    = errors.New("password reset token is invalid, used or expired")
+)
+
 var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
@@ -25,3 +50,12 @@ func ErrorCode(err error) string {
 	}
 	return ""
 }
+
+// ErrorConstraint returns the name of the constraint or index a postgres error is about
+func ErrorConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
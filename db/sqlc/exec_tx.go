@@ -2,7 +2,10 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // execTx executes a function within a database transaction
@@ -23,3 +26,27 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 
 	return tx.Commit(ctx)
 }
+
+// execSavepoint executes a function within a savepoint of the transaction q belongs to,
+// so an error undoes only what the function wrote and the rest of the transaction can go on
+func execSavepoint(ctx context.Context, q *Queries, fn func(*Queries) error) error {
+	tx, ok := q.db.(pgx.Tx)
+	if !ok {
+		return errors.New("savepoint needs a transaction")
+	}
+
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(New(savepoint))
+	if err != nil {
+		if rbErr := savepoint.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("savepoint err: %v, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return savepoint.Commit(ctx)
+}
@@ -8,16 +8,53 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
-	ID            int64     `json:"id"`
-	UserID        int64     `json:"user_id"`
-	AccountNumber int64     `json:"account_number"`
+	ID     int64       `json:"id"`
+	UserID pgtype.Int8 `json:"user_id"`
+	// 10 digit NUBAN with its leading zeros, system accounts all have 0000000000
+	AccountNumber string    `json:"account_number"`
 	Status        string    `json:"status"`
 	Balance       int64     `json:"balance"`
 	CurrencyCode  string    `json:"currency_code"`
 	CreatedAt     time.Time `json:"created_at"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// customer accounts belong to user_id, the other kinds are system accounts the bank owns, one per currency
+	Kind string `json:"kind"`
+}
+
+type AccountStatusHistory struct {
+	ID         int64  `json:"id"`
+	AccountID  int64  `json:"account_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	// id of the user that made the change
+	ChangedBy int64     `json:"changed_by"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BalanceSnapshot struct {
+	AccountID int64 `json:"account_id"`
+	// start of a UTC day, the balance includes every entry created before it
+	SnapshotAt time.Time `json:"snapshot_at"`
+	Balance    int64     `json:"balance"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Currency struct {
+	// ISO 4217 code
+	Code string `json:"code"`
+	// decimal places of the currency, amounts in it are stored as whole numbers of its minor unit
+	MinorUnits int32  `json:"minor_units"`
+	Symbol     string `json:"symbol"`
+	// accounts can only be opened and money only moved in enabled currencies, existing accounts in a disabled one are kept
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Entry struct {
@@ -27,6 +64,108 @@ type Entry struct {
 	Amount      int64     `json:"amount"`
 	DebitCredit string    `json:"debit_credit"`
 	CreatedAt   time.Time `json:"created_at"`
+	// the transfer that wrote the entry, null for entries written before transfers were linked
+	TransferID pgtype.Int8 `json:"transfer_id"`
+}
+
+type FeeRule struct {
+	ID           int64  `json:"id"`
+	CurrencyCode string `json:"currency_code"`
+	// the rule applies to transfers of at least this amount, until a rule with a higher min_amount takes over
+	MinAmount int64 `json:"min_amount"`
+	FlatFee   int64 `json:"flat_fee"`
+	// basis points of the amount, added to flat_fee and truncated
+	PercentageBps int64     `json:"percentage_bps"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Hold struct {
+	ID          int64 `json:"id"`
+	AccountID   int64 `json:"account_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// reserved on account_id until the hold is captured, voided or expires
+	Amount       int64  `json:"amount"`
+	CurrencyCode string `json:"currency_code"`
+	// how much was settled, the rest is released on capture
+	CapturedAmount int64       `json:"captured_amount"`
+	TransferID     pgtype.Int8 `json:"transfer_id"`
+	Status         string      `json:"status"`
+	ExpiresAt      time.Time   `json:"expires_at"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	ID          int64  `json:"id"`
+	UserID      int64  `json:"user_id"`
+	Key         string `json:"key"`
+	RequestPath string `json:"request_path"`
+	RequestHash string `json:"request_hash"`
+	// 0 while the original request is still in flight
+	ResponseCode int32     `json:"response_code"`
+	ResponseBody []byte    `json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiredAt    time.Time `json:"expired_at"`
+}
+
+type PasswordReset struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+	// sha256 of the secret code emailed to the user, the code itself is never stored
+	SecretCodeHash string    `json:"secret_code_hash"`
+	IsUsed         bool      `json:"is_used"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiredAt      time.Time `json:"expired_at"`
+}
+
+type ReconciliationDiscrepancy struct {
+	ID    int64 `json:"id"`
+	RunID int64 `json:"run_id"`
+	// account_balance compares the balance with its entries, transfer_debit and transfer_credit compare a transfer with the entries of its from and to account
+	Kind       string      `json:"kind"`
+	AccountID  int64       `json:"account_id"`
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	// the sum of the entries for account_balance, the transfer amount for transfer_debit and transfer_credit
+	Expected int64 `json:"expected"`
+	// the stored balance for account_balance, the sum of the entries for transfer_debit and transfer_credit
+	Actual    int64     `json:"actual"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReconciliationRun struct {
+	ID               int64     `json:"id"`
+	TriggeredBy      string    `json:"triggered_by"`
+	AccountsChecked  int64     `json:"accounts_checked"`
+	TransfersChecked int64     `json:"transfers_checked"`
+	Discrepancies    int64     `json:"discrepancies"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	UserID        int64  `json:"user_id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	CurrencyCode  string `json:"currency_code"`
+	// cron expression or @every duration, empty for a one-off transfer
+	Schedule  string             `json:"schedule"`
+	Status    string             `json:"status"`
+	NextRunAt time.Time          `json:"next_run_at"`
+	LastRunAt pgtype.Timestamptz `json:"last_run_at"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64       `json:"id"`
+	ScheduledTransferID int64       `json:"scheduled_transfer_id"`
+	TransferID          pgtype.Int8 `json:"transfer_id"`
+	Status              string      `json:"status"`
+	FailureReason       string      `json:"failure_reason"`
+	// the time the run was scheduled for
+	RunAt     time.Time `json:"run_at"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Session struct {
@@ -38,15 +177,72 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	// id of the session the user logged in with, every session rotated from it shares it
+	FamilyID uuid.UUID `json:"family_id"`
+	// session issued when the refresh token was renewed, a retired refresh token must never be used again
+	ReplacedBy pgtype.UUID `json:"replaced_by"`
 }
 
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	// must be positive
+	// must be positive, in the currency of from_account_id
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// must be positive, in the currency of to_account_id
+	ToAmount int64 `json:"to_amount"`
+	// to_amount = amount * exchange_rate, truncated
+	ExchangeRate pgtype.Numeric `json:"exchange_rate"`
+	Status       string         `json:"status"`
+	// the transfer this one reverses, null for ordinary transfers
+	ReversalOf pgtype.Int8 `json:"reversal_of"`
+	// how much of amount has been reversed so far
+	ReversedAmount int64 `json:"reversed_amount"`
+	// charged to from_account_id on top of amount, in its currency, and credited to its fee revenue account
+	Fee int64 `json:"fee"`
+}
+
+type TransferBatch struct {
+	ID            int64  `json:"id"`
+	UserID        int64  `json:"user_id"`
+	FromAccountID int64  `json:"from_account_id"`
+	CurrencyCode  string `json:"currency_code"`
+	// the format of the uploaded file, csv or ISO 20022 pain.001
+	Format      string             `json:"format"`
+	Status      string             `json:"status"`
+	ItemCount   int64              `json:"item_count"`
+	TotalAmount int64              `json:"total_amount"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+}
+
+type TransferBatchItem struct {
+	ID      int64 `json:"id"`
+	BatchID int64 `json:"batch_id"`
+	// the row of a csv file or the position of the transaction in a pain.001 file, counting from 1
+	LineNumber    int32       `json:"line_number"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        int64       `json:"amount"`
+	Reference     string      `json:"reference"`
+	Status        string      `json:"status"`
+	TransferID    pgtype.Int8 `json:"transfer_id"`
+	FailureReason string      `json:"failure_reason"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+type TransferLimit struct {
+	Tier         string `json:"tier"`
+	CurrencyCode string `json:"currency_code"`
+	// largest single transfer, in currency_code
+	PerTransaction int64 `json:"per_transaction"`
+	// total a user can send per UTC calendar day from their currency_code accounts
+	Daily int64 `json:"daily"`
+	// total a user can send per UTC calendar month from their currency_code accounts
+	Monthly   int64     `json:"monthly"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type User struct {
@@ -60,6 +256,8 @@ type User struct {
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
+	Tier              string    `json:"tier"`
 }
 
 type VerifyEmail struct {
@@ -10,11 +10,85 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const blockOtherUserSessions = `-- name: BlockOtherUserSessions :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE email = $1 AND id <> $2 AND is_blocked = false AND expires_at > now()
+`
+
+type BlockOtherUserSessionsParams struct {
+	Email string    `json:"email"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func (q *Queries) BlockOtherUserSessions(ctx context.Context, arg BlockOtherUserSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, blockOtherUserSessions, arg.Email, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const blockSession = `-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1
+RETURNING id, email, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by
+`
+
+func (q *Queries) BlockSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.db.QueryRow(ctx, blockSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const blockSessionFamily = `-- name: BlockSessionFamily :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1 AND is_blocked = false
+`
+
+func (q *Queries) BlockSessionFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, blockSessionFamily, familyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const blockUserSessions = `-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE email = $1 AND is_blocked = false AND expires_at > now()
+`
+
+func (q *Queries) BlockUserSessions(ctx context.Context, email string) (int64, error) {
+	result, err := q.db.Exec(ctx, blockUserSessions, email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
     id,
+    family_id,
     email,
     refresh_token,
     user_agent,
@@ -22,12 +96,13 @@ INSERT INTO sessions (
     is_blocked,
     expires_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, email, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, email, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by
 `
 
 type CreateSessionParams struct {
 	ID           uuid.UUID `json:"id"`
+	FamilyID     uuid.UUID `json:"family_id"`
 	Email        string    `json:"email"`
 	RefreshToken string    `json:"refresh_token"`
 	UserAgent    string    `json:"user_agent"`
@@ -39,6 +114,7 @@ type CreateSessionParams struct {
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
 	row := q.db.QueryRow(ctx, createSession,
 		arg.ID,
+		arg.FamilyID,
 		arg.Email,
 		arg.RefreshToken,
 		arg.UserAgent,
@@ -56,12 +132,14 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
 	)
 	return i, err
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, email, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions 
+SELECT id, email, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by FROM sessions 
 WHERE id = $1 LIMIT 1
 `
 
@@ -77,6 +155,103 @@ func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+	)
+	return i, err
+}
+
+const getSessionAuth = `-- name: GetSessionAuth :one
+SELECT sessions.id, sessions.email, sessions.is_blocked, sessions.expires_at, users.password_changed_at
+FROM sessions
+JOIN users ON users.email = sessions.email
+WHERE sessions.id = $1 LIMIT 1
+`
+
+type GetSessionAuthRow struct {
+	ID                uuid.UUID `json:"id"`
+	Email             string    `json:"email"`
+	IsBlocked         bool      `json:"is_blocked"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+func (q *Queries) GetSessionAuth(ctx context.Context, id uuid.UUID) (GetSessionAuthRow, error) {
+	row := q.db.QueryRow(ctx, getSessionAuth, id)
+	var i GetSessionAuthRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.PasswordChangedAt,
+	)
+	return i, err
+}
+
+const listActiveSessions = `-- name: ListActiveSessions :many
+SELECT id, email, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by FROM sessions
+WHERE email = $1 AND is_blocked = false AND replaced_by IS NULL AND expires_at > now()
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListActiveSessions(ctx context.Context, email string) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listActiveSessions, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.FamilyID,
+			&i.ReplacedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retireSession = `-- name: RetireSession :one
+UPDATE sessions
+SET replaced_by = $2
+WHERE id = $1 AND replaced_by IS NULL
+RETURNING id, email, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by
+`
+
+type RetireSessionParams struct {
+	ID         uuid.UUID   `json:"id"`
+	ReplacedBy pgtype.UUID `json:"replaced_by"`
+}
+
+func (q *Queries) RetireSession(ctx context.Context, arg RetireSessionParams) (Session, error) {
+	row := q.db.QueryRow(ctx, retireSession, arg.ID, arg.ReplacedBy)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
 	)
 	return i, err
 }
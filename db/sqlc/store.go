@@ -11,6 +11,26 @@ type Store interface {
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
 	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
 	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error)
+	CreateScheduledTransferTx(ctx context.Context, arg CreateScheduledTransferTxParams) (CreateScheduledTransferTxResult, error)
+	ExecuteScheduledTransferTx(ctx context.Context, arg ExecuteScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error)
+	PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (PlaceHoldTxResult, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	VoidHoldTx(ctx context.Context, holdID int64) (Hold, error)
+	GetRemainingTransferLimits(ctx context.Context, userID int64) (GetRemainingTransferLimitsResult, error)
+	DepositTx(ctx context.Context, arg LedgerTxParams) (TransferTxResult, error)
+	WithdrawTx(ctx context.Context, arg LedgerTxParams) (TransferTxResult, error)
+	ReconcileLedgerTx(ctx context.Context, arg ReconcileLedgerTxParams) (ReconcileLedgerTxResult, error)
+	GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error)
+	CreateTransferBatchTx(ctx context.Context, arg CreateTransferBatchTxParams) (CreateTransferBatchTxResult, error)
+	ExecuteTransferBatchItemTx(ctx context.Context, itemID int64) (ExecuteTransferBatchItemTxResult, error)
+	CreateCurrencyTx(ctx context.Context, arg CreateCurrencyParams) (Currency, error)
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error)
+	CreatePasswordResetTx(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (ChangePasswordTxResult, error)
+	ChangeEmailTx(ctx context.Context, arg ChangeEmailTxParams) (ChangeEmailTxResult, error)
 }
 
 type SQLStore struct {
@@ -2,15 +2,20 @@ package db
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
+	"time"
 
+	"github.com/kelvinator07/golang-bank-microservices/fx"
+	"github.com/kelvinator07/golang-bank-microservices/util"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestTransferTx(t *testing.T) {
 
-	testAccount1 := createRandomAccount(t)
-	testAccount2 := createRandomAccount(t)
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.USD)
 
 	// run n concurrent transactions
 	n := 5
@@ -111,8 +116,8 @@ func TestTransferTx(t *testing.T) {
 }
 
 func TestTransferTxDeadlock(t *testing.T) {
-	testAccount1 := createRandomAccount(t)
-	testAccount2 := createRandomAccount(t)
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.USD)
 
 	// run n concurrent transactions
 	n := 10
@@ -155,3 +160,765 @@ func TestTransferTxDeadlock(t *testing.T) {
 	assert.Equal(t, testAccount2.Balance, updateAccount2.Balance)
 
 }
+
+func TestTransferTxInactiveAccount(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusInactive, util.USD)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        1,
+	})
+	assert.ErrorIs(t, err, ErrAccountNotActive)
+
+	// the whole transaction is rolled back
+	updateAccount1, err := testStore.GetAccount(context.Background(), testAccount1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, testAccount1.Balance, updateAccount1.Balance)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.USD)
+
+	// run n concurrent transactions that together need more than the balance
+	n := 5
+	amount := testAccount1.Balance/2 + 1
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: testAccount1.ID,
+				ToAccountID:   testAccount2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInsufficientFunds)
+	}
+	assert.Equal(t, 1, succeeded)
+
+	updateAccount1, err := testStore.GetAccount(context.Background(), testAccount1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, testAccount1.Balance-amount, updateAccount1.Balance)
+	assert.GreaterOrEqual(t, updateAccount1.Balance, int64(0))
+}
+
+func TestTransferTxOverdraft(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.USD)
+
+	limit := int64(100)
+	testAccount1, err := testStore.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             testAccount1.ID,
+		OverdraftLimit: limit,
+	})
+	assert.NoError(t, err)
+
+	// spending the whole credit line is allowed
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        testAccount1.Balance + limit,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, -limit, result.FromAccount.Balance)
+	assert.True(t, result.EnteredOverdraft())
+
+	// going one unit past it is not
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        1,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestTransferTxLimits(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.USD)
+
+	// the overdraft keeps the funds check out of the way, standard USD allows 5,000.00 per transfer and 10,000.00 a day
+	_, err := testStore.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             testAccount1.ID,
+		OverdraftLimit: 5000000,
+	})
+	assert.NoError(t, err)
+
+	var limitErr *TransferLimitError
+
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        500001,
+	})
+	assert.ErrorIs(t, err, ErrTransferLimitExceeded)
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, TransferLimitPerTransaction, limitErr.Limit)
+
+	for i := 0; i < 2; i++ {
+		_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: testAccount1.ID,
+			ToAccountID:   testAccount2.ID,
+			Amount:        400000,
+		})
+		assert.NoError(t, err)
+	}
+
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        400000,
+	})
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, TransferLimitDaily, limitErr.Limit)
+	assert.Equal(t, int64(200000), limitErr.Remaining)
+
+	result, err := testStore.GetRemainingTransferLimits(context.Background(), testAccount1.UserID.Int64)
+	assert.NoError(t, err)
+	assert.Equal(t, UserTierStandard, result.Tier)
+	for _, limit := range result.Limits {
+		if limit.CurrencyCode == util.USD {
+			assert.Equal(t, int64(200000), limit.DailyRemaining)
+			assert.Equal(t, limit.Monthly-800000, limit.MonthlyRemaining)
+		}
+	}
+}
+
+func TestTransferTxSameAccount(t *testing.T) {
+	testAccount := createTestAccount(t, AccountStatusActive, util.USD)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount.ID,
+		ToAccountID:   testAccount.ID,
+		Amount:        10,
+	})
+	assert.ErrorIs(t, err, ErrSameAccount)
+
+	account, err := testStore.GetAccount(context.Background(), testAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, testAccount.Balance, account.Balance)
+}
+
+func TestTransferTxDisabledCurrency(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.NGN)
+
+	registered := util.Currencies()
+	t.Cleanup(func() { util.SetCurrencies(registered) })
+
+	ngn, ok := util.LookupCurrency(util.NGN)
+	assert.True(t, ok)
+	ngn.Enabled = false
+	util.RegisterCurrency(ngn)
+
+	rate, err := fx.NewRate(util.USD, util.NGN, "1500")
+	assert.NoError(t, err)
+
+	// the from account's currency is enabled, the money still can't go into a disabled one
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        10,
+		ExchangeRate:  &rate,
+	})
+	assert.ErrorIs(t, err, ErrCurrencyDisabled)
+
+	account, err := testStore.GetAccount(context.Background(), testAccount2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, testAccount2.Balance, account.Balance)
+}
+
+func TestTransferTxFee(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.EUR)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.EUR)
+
+	// 1 plus 1% up to 49, 2 plus 0.5% from 50
+	for _, arg := range []CreateFeeRuleParams{
+		{CurrencyCode: util.EUR, MinAmount: 0, FlatFee: 1, PercentageBps: 100},
+		{CurrencyCode: util.EUR, MinAmount: 50, FlatFee: 2, PercentageBps: 50},
+	} {
+		rule, err := testStore.CreateFeeRule(context.Background(), arg)
+		assert.NoError(t, err)
+		defer testStore.DeleteFeeRule(context.Background(), rule.ID)
+	}
+
+	feeAccount, err := testStore.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Kind:         AccountKindFeeRevenue,
+		CurrencyCode: util.EUR,
+	})
+	assert.NoError(t, err)
+
+	amount := int64(80)
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        amount,
+	})
+	assert.NoError(t, err)
+
+	fee := int64(2)
+	assert.Equal(t, fee, result.Fee)
+	assert.Equal(t, fee, result.Transfer.Fee)
+	assert.Equal(t, -fee, result.FeeEntry.Amount)
+	assert.Equal(t, testAccount1.ID, result.FeeEntry.AccountID)
+	assert.Equal(t, testAccount1.Balance-amount-fee, result.FromAccount.Balance)
+	assert.Equal(t, testAccount2.Balance+amount, result.ToAccount.Balance)
+
+	updatedFeeAccount, err := testStore.GetAccount(context.Background(), feeAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, feeAccount.Balance+fee, updatedFeeAccount.Balance)
+
+	// the fee counts against the funds too
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        result.FromAccount.Balance,
+	})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestFeeRuleFee(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rule   FeeRule
+		amount int64
+		fee    int64
+		err    error
+	}{
+		{name: "Flat", rule: FeeRule{FlatFee: 5}, amount: 1000, fee: 5},
+		{name: "Percentage", rule: FeeRule{PercentageBps: 150}, amount: 1000, fee: 15},
+		{name: "Combined", rule: FeeRule{FlatFee: 5, PercentageBps: 150}, amount: 1000, fee: 20},
+		// percentages are truncated
+		{name: "Truncated", rule: FeeRule{PercentageBps: 150}, amount: 99, fee: 1},
+		// amount * percentage_bps is past the int64 limit, the fee itself isn't
+		{name: "LargeAmount", rule: FeeRule{PercentageBps: 150}, amount: math.MaxInt64 / 100, fee: 1383505805528216},
+		{name: "FeeOverflow", rule: FeeRule{FlatFee: math.MaxInt64, PercentageBps: 1}, amount: 10000, err: ErrAmountTooLarge},
+		{name: "AmountWithFeeOverflow", rule: FeeRule{FlatFee: 1}, amount: math.MaxInt64, err: ErrAmountTooLarge},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := tc.rule.Fee(tc.amount)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.fee, fee)
+		})
+	}
+}
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	testAccount := createTestAccount(t, AccountStatusInactive, util.USD)
+
+	result, err := testStore.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: testAccount.ID,
+		Status:    AccountStatusActive,
+		ChangedBy: testAccount.UserID.Int64,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, AccountStatusActive, result.Account.Status)
+	assert.Equal(t, AccountStatusInactive, result.StatusHistory.FromStatus)
+	assert.Equal(t, AccountStatusActive, result.StatusHistory.ToStatus)
+	assert.Equal(t, testAccount.UserID.Int64, result.StatusHistory.ChangedBy)
+
+	// frozen accounts can only be unfrozen
+	_, err = testStore.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: testAccount.ID,
+		Status:    AccountStatusFrozen,
+		ChangedBy: testAccount.UserID.Int64,
+	})
+	assert.NoError(t, err)
+
+	_, err = testStore.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: testAccount.ID,
+		Status:    AccountStatusClosed,
+		ChangedBy: testAccount.UserID.Int64,
+	})
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+}
+
+func TestTransferTxExchangeRate(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.NGN)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        10,
+	})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	rate, err := fx.NewRate(util.USD, util.NGN, "1500.25")
+	assert.NoError(t, err)
+
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        10,
+		ExchangeRate:  &rate,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(10), result.Transfer.Amount)
+	assert.Equal(t, int64(15002), result.Transfer.ToAmount)
+	assert.Equal(t, int64(-10), result.FromEntry.Amount)
+	assert.Equal(t, int64(15002), result.ToEntry.Amount)
+	assert.Equal(t, testAccount1.Balance-10, result.FromAccount.Balance)
+	assert.Equal(t, testAccount2.Balance+15002, result.ToAccount.Balance)
+
+	exchangeRate, err := result.Transfer.ExchangeRate.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "1500.2500000000", exchangeRate)
+
+	// each currency balances through its suspense account
+	usdSuspense, err := testStore.GetSystemAccount(context.Background(), GetSystemAccountParams{Kind: AccountKindSuspense, CurrencyCode: util.USD})
+	assert.NoError(t, err)
+	ngnSuspense, err := testStore.GetSystemAccount(context.Background(), GetSystemAccountParams{Kind: AccountKindSuspense, CurrencyCode: util.NGN})
+	assert.NoError(t, err)
+
+	result, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        10,
+		ExchangeRate:  &rate,
+	})
+	assert.NoError(t, err)
+
+	updatedUsdSuspense, err := testStore.GetAccount(context.Background(), usdSuspense.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, usdSuspense.Balance+10, updatedUsdSuspense.Balance)
+
+	updatedNgnSuspense, err := testStore.GetAccount(context.Background(), ngnSuspense.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ngnSuspense.Balance-15002, updatedNgnSuspense.Balance)
+}
+
+func TestLedgerTx(t *testing.T) {
+	testAccount := createTestAccount(t, AccountStatusActive, util.USD)
+
+	funding, err := testStore.GetSystemAccount(context.Background(), GetSystemAccountParams{Kind: AccountKindFunding, CurrencyCode: util.USD})
+	assert.NoError(t, err)
+
+	deposit, err := testStore.DepositTx(context.Background(), LedgerTxParams{AccountID: testAccount.ID, Amount: 500})
+	assert.NoError(t, err)
+	assert.Equal(t, funding.ID, deposit.FromAccount.ID)
+	assert.Equal(t, funding.Balance-500, deposit.FromAccount.Balance)
+	assert.Equal(t, testAccount.Balance+500, deposit.ToAccount.Balance)
+
+	withdrawal, err := testStore.WithdrawTx(context.Background(), LedgerTxParams{AccountID: testAccount.ID, Amount: 200})
+	assert.NoError(t, err)
+	assert.Equal(t, AccountKindWithdrawal, withdrawal.ToAccount.Kind)
+	assert.Equal(t, testAccount.Balance+300, withdrawal.FromAccount.Balance)
+
+	_, err = testStore.WithdrawTx(context.Background(), LedgerTxParams{AccountID: testAccount.ID, Amount: testAccount.Balance + 301})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = testStore.DepositTx(context.Background(), LedgerTxParams{AccountID: funding.ID, Amount: 1})
+	assert.ErrorIs(t, err, ErrNotCustomerAccount)
+
+	// customers can't send money to system accounts
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount.ID,
+		ToAccountID:   funding.ID,
+		Amount:        1,
+	})
+	assert.ErrorIs(t, err, ErrNotCustomerAccount)
+
+	rows, err := testStore.GetTrialBalance(context.Background())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, NewTrialBalances(rows))
+}
+
+func TestNewTrialBalances(t *testing.T) {
+	balances := NewTrialBalances([]GetTrialBalanceRow{
+		{CurrencyCode: util.EUR, Kind: AccountKindCustomer, Accounts: 2, Balance: 300},
+		{CurrencyCode: util.EUR, Kind: AccountKindFunding, Accounts: 1, Balance: -300},
+		{CurrencyCode: util.USD, Kind: AccountKindCustomer, Accounts: 1, Balance: 100},
+	})
+
+	assert.Len(t, balances, 2)
+	assert.Equal(t, util.EUR, balances[0].CurrencyCode)
+	assert.Len(t, balances[0].Lines, 2)
+	assert.Zero(t, balances[0].Total)
+	assert.True(t, balances[0].Balanced)
+	assert.Equal(t, int64(100), balances[1].Total)
+	assert.False(t, balances[1].Balanced)
+}
+
+func TestReconcileLedgerTx(t *testing.T) {
+	// test accounts are opened with a balance and no entries, so they always drift from their entries
+	testAccount := createTestAccount(t, AccountStatusActive, util.USD)
+
+	_, err := testStore.DepositTx(context.Background(), LedgerTxParams{AccountID: testAccount.ID, Amount: 10})
+	assert.NoError(t, err)
+
+	result, err := testStore.ReconcileLedgerTx(context.Background(), ReconcileLedgerTxParams{TriggeredBy: ReconciliationTriggeredByCLI})
+	assert.NoError(t, err)
+	assert.Equal(t, ReconciliationTriggeredByCLI, result.Run.TriggeredBy)
+	assert.Positive(t, result.Run.AccountsChecked)
+	assert.Positive(t, result.Run.TransfersChecked)
+	assert.Equal(t, int64(len(result.Discrepancies)), result.Run.Discrepancies)
+
+	var found bool
+	for _, discrepancy := range result.Discrepancies {
+		assert.Equal(t, result.Run.ID, discrepancy.RunID)
+		if discrepancy.AccountID == testAccount.ID {
+			found = true
+			assert.Equal(t, DiscrepancyKindAccountBalance, discrepancy.Kind)
+			assert.Equal(t, int64(10), discrepancy.Expected)
+			assert.Equal(t, testAccount.Balance+10, discrepancy.Actual)
+		}
+	}
+	assert.True(t, found)
+
+	discrepancies, err := testStore.ListReconciliationDiscrepancies(context.Background(), result.Run.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, result.Discrepancies, discrepancies)
+}
+
+func TestGetAccountBalanceAt(t *testing.T) {
+	testAccount := createTestAccount(t, AccountStatusActive, util.USD)
+
+	balanceAt := func(at time.Time) int64 {
+		balance, err := testStore.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{AccountID: testAccount.ID, At: at})
+		assert.NoError(t, err)
+		return balance
+	}
+
+	first, err := testStore.DepositTx(context.Background(), LedgerTxParams{AccountID: testAccount.ID, Amount: 10})
+	assert.NoError(t, err)
+	afterFirst := first.ToEntry.CreatedAt.Add(time.Microsecond)
+
+	// without a snapshot the balance is walked back from the current one
+	assert.Zero(t, balanceAt(testAccount.CreatedAt.Add(-time.Hour)))
+	assert.Equal(t, testAccount.Balance, balanceAt(first.ToEntry.CreatedAt))
+	assert.Equal(t, testAccount.Balance+10, balanceAt(afterFirst))
+
+	count, err := testStore.CreateBalanceSnapshots(context.Background(), afterFirst)
+	assert.NoError(t, err)
+	assert.Positive(t, count)
+
+	snapshot, err := testStore.GetLatestBalanceSnapshot(context.Background(), GetLatestBalanceSnapshotParams{AccountID: testAccount.ID, At: afterFirst})
+	assert.NoError(t, err)
+	assert.Equal(t, testAccount.Balance+10, snapshot.Balance)
+
+	// a second run for the same time leaves the snapshots alone
+	count, err = testStore.CreateBalanceSnapshots(context.Background(), afterFirst)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	second, err := testStore.DepositTx(context.Background(), LedgerTxParams{AccountID: testAccount.ID, Amount: 5})
+	assert.NoError(t, err)
+
+	assert.Equal(t, testAccount.Balance+10, balanceAt(second.ToEntry.CreatedAt))
+	assert.Equal(t, testAccount.Balance+15, balanceAt(second.ToEntry.CreatedAt.Add(time.Microsecond)))
+}
+
+func TestBalanceSnapshotTime(t *testing.T) {
+	lagos := time.FixedZone("WAT", 60*60)
+	at := time.Date(2024, 3, 1, 0, 30, 0, 0, lagos)
+
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), BalanceSnapshotTime(at))
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.USD)
+
+	nextRunAt := time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+	scheduledTransfer, err := testStore.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		UserID:        testAccount1.UserID.Int64,
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        10,
+		CurrencyCode:  util.USD,
+		Schedule:      "@every 1h",
+		NextRunAt:     nextRunAt,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ScheduledTransferStatusActive, scheduledTransfer.Status)
+
+	result, err := testStore.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               scheduledTransfer.NextRunAt,
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Transfer)
+	assert.Equal(t, ScheduledTransferRunStatusSucceeded, result.Run.Status)
+	assert.Equal(t, result.Transfer.Transfer.ID, result.Run.TransferID.Int64)
+	assert.Equal(t, testAccount1.Balance-10, result.Transfer.FromAccount.Balance)
+	assert.Equal(t, ScheduledTransferStatusActive, result.ScheduledTransfer.Status)
+	assert.True(t, result.ScheduledTransfer.NextRunAt.After(time.Now()))
+	assert.True(t, result.ScheduledTransfer.LastRunAt.Valid)
+
+	// the same run can't be made twice
+	_, err = testStore.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               scheduledTransfer.NextRunAt,
+	})
+	assert.ErrorIs(t, err, ErrScheduledTransferNotDue)
+
+	// a one-off transfer the account can't cover is recorded as failed and completes
+	oneOff, err := testStore.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		UserID:        testAccount1.UserID.Int64,
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        testAccount1.Balance + 1,
+		CurrencyCode:  util.USD,
+		NextRunAt:     nextRunAt,
+	})
+	assert.NoError(t, err)
+
+	result, err = testStore.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{
+		ScheduledTransferID: oneOff.ID,
+		RunAt:               oneOff.NextRunAt,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, result.Transfer)
+	assert.Equal(t, ScheduledTransferRunStatusFailed, result.Run.Status)
+	assert.NotEmpty(t, result.Run.FailureReason)
+	assert.False(t, result.Run.TransferID.Valid)
+	assert.Equal(t, ScheduledTransferStatusCompleted, result.ScheduledTransfer.Status)
+}
+
+func TestTransferFailureReason(t *testing.T) {
+	limitErr := &TransferLimitError{Tier: UserTierStandard, CurrencyCode: util.USD}
+
+	testCases := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{name: "NoError"},
+		{name: "TransferLimit", err: limitErr, reason: limitErr.Error()},
+		{name: "AmountTooLarge", err: fmt.Errorf("%w: 10", ErrAmountTooLarge), reason: "amount is too large: 10"},
+		{name: "InsufficientFunds", err: ErrInsufficientFunds, reason: ErrInsufficientFunds.Error()},
+		{name: "AccountNotActive", err: ErrAccountNotActive, reason: ErrAccountNotActive.Error()},
+		{name: "CurrencyMismatch", err: ErrCurrencyMismatch, reason: ErrCurrencyMismatch.Error()},
+		{name: "CurrencyDisabled", err: ErrCurrencyDisabled, reason: ErrCurrencyDisabled.Error()},
+		{name: "SameAccount", err: ErrSameAccount, reason: ErrSameAccount.Error()},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			reason, err := transferFailureReason(tc.err)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.reason, reason)
+		})
+	}
+
+	// anything else could go differently on a retry
+	reason, err := transferFailureReason(ErrRecordNotFound)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+	assert.Empty(t, reason)
+}
+
+func TestTransferBatchTx(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount3 := createTestAccount(t, AccountStatusFrozen, util.USD)
+
+	result, err := testStore.CreateTransferBatchTx(context.Background(), CreateTransferBatchTxParams{
+		CreateTransferBatchParams: CreateTransferBatchParams{
+			UserID:        testAccount1.UserID.Int64,
+			FromAccountID: testAccount1.ID,
+			CurrencyCode:  util.USD,
+			Format:        TransferBatchFormatCSV,
+			ItemCount:     2,
+			TotalAmount:   20,
+		},
+		Items: []CreateTransferBatchItemParams{
+			{LineNumber: 2, ToAccountID: testAccount2.ID, Amount: 10, Reference: "salary"},
+			{LineNumber: 3, ToAccountID: testAccount3.ID, Amount: 10, Reference: "salary"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TransferBatchStatusPending, result.Batch.Status)
+	assert.Len(t, result.Items, 2)
+
+	itemIDs, err := testStore.ListPendingTransferBatchItemIDs(context.Background(), result.Batch.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{result.Items[0].ID, result.Items[1].ID}, itemIDs)
+
+	completed, err := testStore.ExecuteTransferBatchItemTx(context.Background(), itemIDs[0])
+	assert.NoError(t, err)
+	assert.NotNil(t, completed.Transfer)
+	assert.Equal(t, TransferBatchItemStatusCompleted, completed.Item.Status)
+	assert.Equal(t, completed.Transfer.Transfer.ID, completed.Item.TransferID.Int64)
+	assert.Equal(t, testAccount1.Balance-10, completed.Transfer.FromAccount.Balance)
+
+	// the frozen account can't be credited, the item fails without stopping the batch
+	failed, err := testStore.ExecuteTransferBatchItemTx(context.Background(), itemIDs[1])
+	assert.NoError(t, err)
+	assert.Nil(t, failed.Transfer)
+	assert.Equal(t, TransferBatchItemStatusFailed, failed.Item.Status)
+	assert.Equal(t, fmt.Sprintf("account [%d] is frozen", testAccount3.ID), failed.Item.FailureReason)
+
+	// an item is only ever made once
+	again, err := testStore.ExecuteTransferBatchItemTx(context.Background(), itemIDs[0])
+	assert.NoError(t, err)
+	assert.Nil(t, again.Transfer)
+	assert.Equal(t, completed.Item, again.Item)
+
+	summary, err := testStore.GetTransferBatchSummary(context.Background(), result.Batch.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, GetTransferBatchSummaryRow{CompletedItems: 1, FailedItems: 1, CompletedAmount: 10, FailedAmount: 10}, summary)
+	assert.Equal(t, TransferBatchStatusCompletedWithErrors, TransferBatchStatus(summary))
+}
+
+func TestTransferBatchStatus(t *testing.T) {
+	assert.Equal(t, TransferBatchStatusCompleted, TransferBatchStatus(GetTransferBatchSummaryRow{CompletedItems: 2}))
+	assert.Equal(t, TransferBatchStatusFailed, TransferBatchStatus(GetTransferBatchSummaryRow{FailedItems: 2}))
+	assert.Equal(t, TransferBatchStatusCompletedWithErrors, TransferBatchStatus(GetTransferBatchSummaryRow{CompletedItems: 1, FailedItems: 1}))
+}
+
+func TestReverseTransferTx(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.NGN)
+
+	rate, err := fx.NewRate(util.USD, util.NGN, "1500.25")
+	assert.NoError(t, err)
+
+	original, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: testAccount1.ID,
+		ToAccountID:   testAccount2.ID,
+		Amount:        10,
+		ExchangeRate:  &rate,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TransferStatusCompleted, original.Transfer.Status)
+
+	// 3/10 of the 15002 NGN that was credited, truncated
+	result, err := testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     3,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TransferStatusPartiallyReversed, result.OriginalTransfer.Status)
+	assert.Equal(t, int64(3), result.OriginalTransfer.ReversedAmount)
+	assert.Equal(t, original.Transfer.ID, result.Transfer.ReversalOf.Int64)
+	assert.Equal(t, testAccount2.ID, result.Transfer.FromAccountID)
+	assert.Equal(t, testAccount1.ID, result.Transfer.ToAccountID)
+	assert.Equal(t, int64(4500), result.Transfer.Amount)
+	assert.Equal(t, int64(3), result.Transfer.ToAmount)
+	assert.Equal(t, int64(-4500), result.FromEntry.Amount)
+	assert.Equal(t, int64(3), result.ToEntry.Amount)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     8,
+	})
+	assert.ErrorIs(t, err, ErrReversalAmountTooLarge)
+
+	// the rest of the transfer, the parts add up to what was credited
+	result, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TransferStatusReversed, result.OriginalTransfer.Status)
+	assert.Equal(t, int64(7), result.Transfer.ToAmount)
+	assert.Equal(t, original.Transfer.ToAmount-4500, result.Transfer.Amount)
+	assert.Equal(t, testAccount1.Balance, result.ToAccount.Balance)
+	assert.Equal(t, testAccount2.Balance, result.FromAccount.Balance)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	assert.ErrorIs(t, err, ErrTransferAlreadyReversed)
+
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: result.Transfer.ID,
+	})
+	assert.ErrorIs(t, err, ErrTransferNotReversible)
+}
+
+func TestReverseLedgerTransferTx(t *testing.T) {
+	testAccount := createTestAccount(t, AccountStatusActive, util.USD)
+
+	deposit, err := testStore.DepositTx(context.Background(), LedgerTxParams{AccountID: testAccount.ID, Amount: 10})
+	assert.NoError(t, err)
+
+	// the customer owns the to account of a deposit, but the money can't go back to the funding account
+	_, err = testStore.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: deposit.Transfer.ID,
+	})
+	assert.ErrorIs(t, err, ErrTransferNotReversible)
+
+	account, err := testStore.GetAccount(context.Background(), testAccount.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, testAccount.Balance+10, account.Balance)
+}
+
+func TestHoldTx(t *testing.T) {
+	testAccount1 := createTestAccount(t, AccountStatusActive, util.USD)
+	testAccount2 := createTestAccount(t, AccountStatusActive, util.USD)
+
+	placeHold := func(amount int64) (PlaceHoldTxResult, error) {
+		return testStore.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+			CreateHoldParams: CreateHoldParams{
+				AccountID:    testAccount1.ID,
+				ToAccountID:  testAccount2.ID,
+				Amount:       amount,
+				CurrencyCode: util.USD,
+				ExpiresAt:    time.Now().Add(time.Hour),
+			},
+			AfterCreate: func(hold Hold) error { return nil },
+		})
+	}
+
+	result, err := placeHold(10)
+	assert.NoError(t, err)
+	assert.Equal(t, HoldStatusActive, result.Hold.Status)
+	assert.Equal(t, testAccount1.Balance-10, result.AvailableBalance)
+
+	// only accounts with something on hold are listed
+	heldAmounts, err := testStore.ListAccountHeldAmounts(context.Background(), []int64{testAccount1.ID, testAccount2.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []ListAccountHeldAmountsRow{{AccountID: testAccount1.ID, HeldAmount: 10}}, heldAmounts)
+
+	// the ledger balance is untouched, only the available balance is reduced
+	_, err = placeHold(testAccount1.Balance - 9)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	captured, err := testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{
+		HoldID: result.Hold.ID,
+		Amount: 6,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, HoldStatusCaptured, captured.Hold.Status)
+	assert.Equal(t, int64(6), captured.Hold.CapturedAmount)
+	assert.Equal(t, captured.Transfer.ID, captured.Hold.TransferID.Int64)
+	assert.Equal(t, testAccount1.Balance-6, captured.FromAccount.Balance)
+	assert.Equal(t, testAccount2.Balance+6, captured.ToAccount.Balance)
+
+	held, err := testStore.GetAccountHeldAmount(context.Background(), testAccount1.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, held)
+
+	_, err = testStore.VoidHoldTx(context.Background(), result.Hold.ID)
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+
+	result, err = placeHold(5)
+	assert.NoError(t, err)
+
+	voided, err := testStore.VoidHoldTx(context.Background(), result.Hold.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, HoldStatusVoided, voided.Status)
+
+	_, err = testStore.ExpireHold(context.Background(), result.Hold.ID)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// every kind but customer is a system account, there is one of each per currency.
+// Money enters the bank through funding and leaves through withdrawal, suspense takes the other side
+// of both legs of a cross currency transfer so that each currency balances on its own
+const (
+	AccountKindCustomer   string = "customer"
+	AccountKindFeeRevenue string = "fee_revenue"
+	AccountKindFunding    string = "funding"
+	AccountKindWithdrawal string = "withdrawal"
+	AccountKindSuspense   string = "suspense"
+)
+
+// OwnedBy reports whether the account is one of the user's customer accounts, system accounts have no owner
+func (account Account) OwnedBy(userID int64) bool {
+	return account.UserID.Valid && account.UserID.Int64 == userID
+}
+
+// systemAccount finds the bank's account of a kind in a currency, there is at most one of each
+func systemAccount(ctx context.Context, q *Queries, kind string, currencyCode string) (Account, error) {
+	account, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+		Kind:         kind,
+		CurrencyCode: currencyCode,
+	})
+	if err != nil {
+		return account, fmt.Errorf("%s account for %s: %w", kind, currencyCode, err)
+	}
+	return account, nil
+}
+
+// TrialBalance lists the balances of one currency by account kind, they total zero when the ledger is balanced
+type TrialBalance struct {
+	CurrencyCode string               `json:"currency_code"`
+	Lines        []GetTrialBalanceRow `json:"lines"`
+	Total        int64                `json:"total"`
+	Balanced     bool                 `json:"balanced"`
+}
+
+// NewTrialBalances groups the rows of GetTrialBalance by currency, keeping their order
+func NewTrialBalances(rows []GetTrialBalanceRow) []TrialBalance {
+	balances := []TrialBalance{}
+	for _, row := range rows {
+		if len(balances) == 0 || balances[len(balances)-1].CurrencyCode != row.CurrencyCode {
+			balances = append(balances, TrialBalance{CurrencyCode: row.CurrencyCode})
+		}
+
+		balance := &balances[len(balances)-1]
+		balance.Lines = append(balance.Lines, row)
+		balance.Total += row.Balance
+	}
+
+	for i := range balances {
+		balances[i].Balanced = balances[i].Total == 0
+	}
+
+	return balances
+}
@@ -7,26 +7,45 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  to_amount,
+  exchange_rate,
+  reversal_of,
+  fee
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, reversal_of, reversed_amount, fee
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64          `json:"from_account_id"`
+	ToAccountID   int64          `json:"to_account_id"`
+	Amount        int64          `json:"amount"`
+	ToAmount      int64          `json:"to_amount"`
+	ExchangeRate  pgtype.Numeric `json:"exchange_rate"`
+	ReversalOf    pgtype.Int8    `json:"reversal_of"`
+	Fee           int64          `json:"fee"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRow(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ReversalOf,
+		arg.Fee,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +53,12 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Fee,
 	)
 	return i, err
 }
@@ -49,7 +74,7 @@ func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, reversal_of, reversed_amount, fee FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -62,12 +87,133 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, reversal_of, reversed_amount, fee FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRow(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Fee,
 	)
 	return i, err
 }
 
+const getUserTransferTotal = `-- name: GetUserTransferTotal :one
+SELECT COALESCE(SUM(t.amount), 0)::bigint AS total
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.user_id = $1
+  AND a.currency_code = $2
+  AND t.reversal_of IS NULL
+  AND t.created_at >= $3
+`
+
+type GetUserTransferTotalParams struct {
+	UserID       int64     `json:"user_id"`
+	CurrencyCode string    `json:"currency_code"`
+	Since        time.Time `json:"since"`
+}
+
+func (q *Queries) GetUserTransferTotal(ctx context.Context, arg GetUserTransferTotalParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getUserTransferTotal, arg.UserID, arg.CurrencyCode, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const listAccountTransfers = `-- name: ListAccountTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, reversal_of, reversed_amount, fee FROM transfers
+WHERE (from_account_id = $1 OR to_account_id = $1)
+  AND id > $2
+  AND ($3::varchar IS NULL
+    OR ($3 = 'debit' AND from_account_id = $1)
+    OR ($3 = 'credit' AND to_account_id = $1))
+  AND ($4::timestamptz IS NULL OR created_at >= $4)
+  AND ($5::timestamptz IS NULL OR created_at < $5)
+  AND ($6::bigint IS NULL OR amount >= $6)
+  AND ($7::bigint IS NULL OR amount <= $7)
+ORDER BY id
+LIMIT $8
+`
+
+type ListAccountTransfersParams struct {
+	AccountID int64              `json:"account_id"`
+	Cursor    int64              `json:"cursor"`
+	Direction pgtype.Text        `json:"direction"`
+	FromDate  pgtype.Timestamptz `json:"from_date"`
+	ToDate    pgtype.Timestamptz `json:"to_date"`
+	MinAmount pgtype.Int8        `json:"min_amount"`
+	MaxAmount pgtype.Int8        `json:"max_amount"`
+	PageSize  int32              `json:"page_size"`
+}
+
+func (q *Queries) ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listAccountTransfers,
+		arg.AccountID,
+		arg.Cursor,
+		arg.Direction,
+		arg.FromDate,
+		arg.ToDate,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.ReversalOf,
+			&i.ReversedAmount,
+			&i.Fee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, reversal_of, reversed_amount, fee FROM transfers
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -93,6 +239,12 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Status,
+			&i.ReversalOf,
+			&i.ReversedAmount,
+			&i.Fee,
 		); err != nil {
 			return nil, err
 		}
@@ -108,7 +260,7 @@ const updateTransfer = `-- name: UpdateTransfer :one
 UPDATE transfers
 SET amount = $2
 WHERE id = $1
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, reversal_of, reversed_amount, fee
 `
 
 type UpdateTransferParams struct {
@@ -125,6 +277,46 @@ func (q *Queries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const updateTransferReversal = `-- name: UpdateTransferReversal :one
+UPDATE transfers
+SET
+  status = $2,
+  reversed_amount = $3
+WHERE id = $1
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, status, reversal_of, reversed_amount, fee
+`
+
+type UpdateTransferReversalParams struct {
+	ID             int64  `json:"id"`
+	Status         string `json:"status"`
+	ReversedAmount int64  `json:"reversed_amount"`
+}
+
+func (q *Queries) UpdateTransferReversal(ctx context.Context, arg UpdateTransferReversalParams) (Transfer, error) {
+	row := q.db.QueryRow(ctx, updateTransferReversal, arg.ID, arg.Status, arg.ReversedAmount)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Status,
+		&i.ReversalOf,
+		&i.ReversedAmount,
+		&i.Fee,
 	)
 	return i, err
 }
@@ -0,0 +1,56 @@
+package db
+
+import "context"
+
+type ChangeEmailTxParams struct {
+	UserID int64
+	Email  string
+	// AfterUpdate runs in the transaction with the updated user, e.g. to queue the verify email for the new address
+	AfterUpdate func(user User) error
+}
+
+type ChangeEmailTxResult struct {
+	User            User
+	RevokedSessions int64
+}
+
+// ChangeEmailTx changes a user's email and marks it unverified. Verify emails and password resets sent to the
+// old address are invalidated, and every session is blocked since tokens carry the old address
+func (store *SQLStore) ChangeEmailTx(ctx context.Context, arg ChangeEmailTxParams) (ChangeEmailTxResult, error) {
+	var result ChangeEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		user, err := q.GetUserForUpdate(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidateVerifyEmails(ctx, user.Email)
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidatePasswordResets(ctx, user.Email)
+		if err != nil {
+			return err
+		}
+
+		result.RevokedSessions, err = q.BlockUserSessions(ctx, user.Email)
+		if err != nil {
+			return err
+		}
+
+		// sessions, verify emails and password resets follow the new address through ON UPDATE CASCADE
+		result.User, err = q.UpdateUserEmail(ctx, UpdateUserEmailParams{
+			ID:    arg.UserID,
+			Email: arg.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		return arg.AfterUpdate(result.User)
+	})
+
+	return result, err
+}
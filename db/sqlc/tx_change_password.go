@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ChangePasswordTxParams struct {
+	Email          string
+	HashedPassword string
+	// SessionID is the session the password was changed from, it is the only one left active
+	SessionID uuid.UUID
+}
+
+type ChangePasswordTxResult struct {
+	User            User
+	RevokedSessions int64
+}
+
+// ChangePasswordTx sets a new password, blocks the user's other sessions and invalidates their unused password resets
+func (store *SQLStore) ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (ChangePasswordTxResult, error) {
+	var result ChangePasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			HashedPassword: pgtype.Text{
+				String: arg.HashedPassword,
+				Valid:  true,
+			},
+			PasswordChangedAt: pgtype.Timestamptz{
+				Time:  time.Now(),
+				Valid: true,
+			},
+			Email: pgtype.Text{
+				String: arg.Email,
+				Valid:  true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidatePasswordResets(ctx, result.User.Email)
+		if err != nil {
+			return err
+		}
+
+		result.RevokedSessions, err = q.BlockOtherUserSessions(ctx, BlockOtherUserSessionsParams{
+			Email: result.User.Email,
+			ID:    arg.SessionID,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,22 @@
+package db
+
+import "context"
+
+// CreateCurrencyTx adds a currency along with the system accounts money in it moves through,
+// without them deposits, withdrawals and fees in the currency would fail
+func (store *SQLStore) CreateCurrencyTx(ctx context.Context, arg CreateCurrencyParams) (Currency, error) {
+	var currency Currency
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		currency, err = q.CreateCurrency(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateSystemAccounts(ctx, currency.Code)
+	})
+
+	return currency, err
+}
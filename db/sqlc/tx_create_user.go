@@ -2,6 +2,11 @@ package db
 
 import "context"
 
+const (
+	UserRoleCustomer = "customer"
+	UserRoleAdmin    = "admin"
+)
+
 type CreateUserTxParams struct {
 	CreateUserParams
 	AfterCreate func(user User) error
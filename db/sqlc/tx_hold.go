@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusVoided   = "voided"
+	HoldStatusExpired  = "expired"
+)
+
+type PlaceHoldTxParams struct {
+	CreateHoldParams
+	AfterCreate func(hold Hold) error
+}
+
+type PlaceHoldTxResult struct {
+	Hold             Hold  `json:"hold"`
+	AvailableBalance int64 `json:"available_balance"`
+}
+
+// PlaceHoldTx reserves part of an account's available balance, AfterCreate queues the hold's expiry
+func (store *SQLStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (PlaceHoldTxResult, error) {
+	var result PlaceHoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// the account row lock serializes holds and transfers on the account while the balance is checked
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Status != AccountStatusActive {
+			return fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, account.ID, account.Status)
+		}
+
+		available, err := availableBalance(ctx, q, account)
+		if err != nil {
+			return err
+		}
+		if available < arg.Amount {
+			return fmt.Errorf("%w: account [%d] has %d available", ErrInsufficientFunds, account.ID, available)
+		}
+
+		result.Hold, err = q.CreateHold(ctx, arg.CreateHoldParams)
+		if err != nil {
+			return err
+		}
+		result.AvailableBalance = available - result.Hold.Amount
+
+		return arg.AfterCreate(result.Hold)
+	})
+
+	return result, err
+}
+
+// CaptureHoldTxParams contains the input parameters of the capture transaction
+// Amount can be less than the hold, zero captures all of it. Whatever isn't captured is released
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	Amount int64 `json:"amount"`
+}
+
+type CaptureHoldTxResult struct {
+	Hold Hold `json:"hold"`
+	TransferTxResult
+}
+
+// CaptureHoldTx settles a hold by transferring the captured amount to the hold's to account
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := activeHoldForUpdate(ctx, q, arg.HoldID)
+		if err != nil {
+			return err
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return fmt.Errorf("%w: %d requested, hold [%d] is for %d", ErrCaptureAmountTooLarge, amount, hold.ID, hold.Amount)
+		}
+
+		// the hold is released before the transfer, otherwise its own reservation would block the funds check
+		_, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:             hold.ID,
+			Status:         HoldStatusCaptured,
+			CapturedAmount: pgtype.Int8{Int64: amount, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:         hold.ID,
+			Status:     HoldStatusCaptured,
+			TransferID: pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// VoidHoldTx releases a hold without moving any money
+func (store *SQLStore) VoidHoldTx(ctx context.Context, holdID int64) (Hold, error) {
+	var result Hold
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := activeHoldForUpdate(ctx, q, holdID)
+		if err != nil {
+			return err
+		}
+
+		result, err = q.UpdateHold(ctx, UpdateHoldParams{
+			ID:     hold.ID,
+			Status: HoldStatusVoided,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// activeHoldForUpdate locks a hold that can still be captured or voided
+func activeHoldForUpdate(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+
+	if hold.Status != HoldStatusActive {
+		return hold, fmt.Errorf("%w: hold [%d] is %s", ErrHoldNotActive, hold.ID, hold.Status)
+	}
+	// the expiry task may not have run yet, an expired hold can't be used either way
+	if !hold.ExpiresAt.After(time.Now()) {
+		return hold, fmt.Errorf("%w: hold [%d] expired at %s", ErrHoldNotActive, hold.ID, hold.ExpiresAt)
+	}
+
+	return hold, nil
+}
+
+// availableBalance is what the account can still spend: the ledger balance and overdraft limit
+// less whatever is reserved by active holds
+func availableBalance(ctx context.Context, q *Queries, account Account) (int64, error) {
+	held, err := q.GetAccountHeldAmount(ctx, account.ID)
+	if err != nil {
+		return 0, err
+	}
+	return AvailableBalance(account, held), nil
+}
+
+// AvailableBalance works out the available balance of an account from the amount it has on hold
+func AvailableBalance(account Account, held int64) int64 {
+	return account.Balance + account.OverdraftLimit - held
+}
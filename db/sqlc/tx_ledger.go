@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// LedgerTxParams contains the input parameters of a deposit or withdrawal
+type LedgerTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// DepositTx credits a customer account with money coming into the bank, the funding account of its currency is debited
+func (store *SQLStore) DepositTx(ctx context.Context, arg LedgerTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := customerAccount(ctx, q, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		funding, err := systemAccount(ctx, q, AccountKindFunding, account.CurrencyCode)
+		if err != nil {
+			return err
+		}
+
+		result, err = postLedgerTransfer(ctx, q, funding, account, arg.Amount)
+		return err
+	})
+
+	return result, err
+}
+
+// WithdrawTx debits a customer account with money leaving the bank, the withdrawal account of its currency is credited.
+// ErrInsufficientFunds is returned when the account can't cover the amount
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg LedgerTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := customerAccount(ctx, q, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		withdrawal, err := systemAccount(ctx, q, AccountKindWithdrawal, account.CurrencyCode)
+		if err != nil {
+			return err
+		}
+
+		result, err = postLedgerTransfer(ctx, q, account, withdrawal, arg.Amount)
+		return err
+	})
+
+	return result, err
+}
+
+func customerAccount(ctx context.Context, q *Queries, accountID int64) (Account, error) {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Kind != AccountKindCustomer {
+		return account, fmt.Errorf("%w: account [%d] is a %s account", ErrNotCustomerAccount, account.ID, account.Kind)
+	}
+
+	return account, nil
+}
+
+// postLedgerTransfer moves money between a customer and a system account of the same currency,
+// without the fees and limits of a customer transfer
+func postLedgerTransfer(ctx context.Context, q *Queries, fromAccount Account, toAccount Account, amount int64) (TransferTxResult, error) {
+	toAmount, exchangeRate, err := exchangeAmount(TransferTxParams{Amount: amount}, fromAccount.CurrencyCode, toAccount.CurrencyCode)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	return moveMoney(ctx, q, CreateTransferParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		ToAmount:      toAmount,
+		ExchangeRate:  exchangeRate,
+	})
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ReconciliationTriggeredBySchedule string = "schedule"
+	ReconciliationTriggeredByCLI      string = "cli"
+)
+
+const (
+	DiscrepancyKindAccountBalance string = "account_balance"
+	DiscrepancyKindTransferDebit  string = "transfer_debit"
+	DiscrepancyKindTransferCredit string = "transfer_credit"
+)
+
+type ReconcileLedgerTxParams struct {
+	TriggeredBy string `json:"triggered_by"`
+}
+
+type ReconcileLedgerTxResult struct {
+	Run           ReconciliationRun           `json:"run"`
+	Discrepancies []ReconciliationDiscrepancy `json:"discrepancies"`
+}
+
+// ReconcileLedgerTx recomputes the balance of every account from its entries and checks every transfer
+// against the entries it wrote, the run and each discrepancy found are recorded.
+// Each check is a single statement so it reads a consistent snapshot while transfers keep running
+func (store *SQLStore) ReconcileLedgerTx(ctx context.Context, arg ReconcileLedgerTxParams) (ReconcileLedgerTxResult, error) {
+	var result ReconcileLedgerTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		accountsChecked, err := q.CountAccounts(ctx)
+		if err != nil {
+			return err
+		}
+
+		transfersChecked, err := q.CountTransfers(ctx)
+		if err != nil {
+			return err
+		}
+
+		var discrepancies []CreateReconciliationDiscrepancyParams
+
+		balances, err := q.ListBalanceMismatches(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, balance := range balances {
+			discrepancies = append(discrepancies, CreateReconciliationDiscrepancyParams{
+				Kind:      DiscrepancyKindAccountBalance,
+				AccountID: balance.ID,
+				Expected:  balance.EntriesTotal,
+				Actual:    balance.Balance,
+			})
+		}
+
+		transfers, err := q.ListUnbalancedTransfers(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, transfer := range transfers {
+			transferID := pgtype.Int8{Int64: transfer.ID, Valid: true}
+
+			if transfer.Debit != transfer.DebitEntries {
+				discrepancies = append(discrepancies, CreateReconciliationDiscrepancyParams{
+					Kind:       DiscrepancyKindTransferDebit,
+					AccountID:  transfer.FromAccountID,
+					TransferID: transferID,
+					Expected:   transfer.Debit,
+					Actual:     transfer.DebitEntries,
+				})
+			}
+
+			if transfer.Credit != transfer.CreditEntries {
+				discrepancies = append(discrepancies, CreateReconciliationDiscrepancyParams{
+					Kind:       DiscrepancyKindTransferCredit,
+					AccountID:  transfer.ToAccountID,
+					TransferID: transferID,
+					Expected:   transfer.Credit,
+					Actual:     transfer.CreditEntries,
+				})
+			}
+		}
+
+		result.Run, err = q.CreateReconciliationRun(ctx, CreateReconciliationRunParams{
+			TriggeredBy:      arg.TriggeredBy,
+			AccountsChecked:  accountsChecked,
+			TransfersChecked: transfersChecked,
+			Discrepancies:    int64(len(discrepancies)),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Discrepancies = make([]ReconciliationDiscrepancy, 0, len(discrepancies))
+		for _, discrepancy := range discrepancies {
+			discrepancy.RunID = result.Run.ID
+
+			recorded, err := q.CreateReconciliationDiscrepancy(ctx, discrepancy)
+			if err != nil {
+				return err
+			}
+			result.Discrepancies = append(result.Discrepancies, recorded)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
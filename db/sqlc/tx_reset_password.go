@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreatePasswordResetTx stores a new password reset and uses up the user's older ones, so only the link in the
+// latest email works
+func (store *SQLStore) CreatePasswordResetTx(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error) {
+	var passwordReset PasswordReset
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.InvalidatePasswordResets(ctx, arg.Email)
+		if err != nil {
+			return err
+		}
+
+		passwordReset, err = q.CreatePasswordReset(ctx, arg)
+		return err
+	})
+
+	return passwordReset, err
+}
+
+type ResetPasswordTxParams struct {
+	SecretCodeHash string
+	HashedPassword string
+}
+
+type ResetPasswordTxResult struct {
+	User            User
+	PasswordReset   PasswordReset
+	RevokedSessions int64
+}
+
+// ResetPasswordTx uses up a password reset, sets the new password and blocks all of the user's sessions, it returns
+// ErrPasswordResetInvalid when no unused and unexpired reset has the secret code
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PasswordReset, err = q.UsePasswordReset(ctx, arg.SecretCodeHash)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrPasswordResetInvalid
+			}
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			HashedPassword: pgtype.Text{
+				String: arg.HashedPassword,
+				Valid:  true,
+			},
+			PasswordChangedAt: pgtype.Timestamptz{
+				Time:  time.Now(),
+				Valid: true,
+			},
+			Email: pgtype.Text{
+				String: result.PasswordReset.Email,
+				Valid:  true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.RevokedSessions, err = q.BlockUserSessions(ctx, result.User.Email)
+		return err
+	})
+
+	return result, err
+}
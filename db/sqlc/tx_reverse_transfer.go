@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kelvinator07/golang-bank-microservices/fx"
+)
+
+const (
+	TransferStatusCompleted         = "completed"
+	TransferStatusPartiallyReversed = "partially_reversed"
+	TransferStatusReversed          = "reversed"
+)
+
+// ReverseTransferTxParams contains the input parameters of the reversal transaction
+// Amount is in the currency of the original from account, zero reverses whatever is left
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	Amount     int64 `json:"amount"`
+}
+
+// ReverseTransferTxResult is the result of the reversal transaction
+type ReverseTransferTxResult struct {
+	OriginalTransfer Transfer `json:"original_transfer"`
+	TransferTxResult
+}
+
+// ReverseTransferTx sends money back from the to account of a transfer to its from account.
+// The compensating transfer is linked to the original through reversal_of and uses the original rate,
+// so a transfer reversed in parts always returns exactly the amount that was sent. The fee isn't refunded and reversals are free
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// locking the original makes concurrent reversals of the same transfer wait for each other
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		if original.ReversalOf.Valid {
+			return fmt.Errorf("%w: transfer [%d] is itself a reversal", ErrTransferNotReversible, original.ID)
+		}
+
+		// deposits, withdrawals and other postings to system accounts are corrected through the ledger, not reversed
+		for _, accountID := range []int64{original.FromAccountID, original.ToAccountID} {
+			if _, err := customerAccount(ctx, q, accountID); err != nil {
+				if errors.Is(err, ErrNotCustomerAccount) {
+					return fmt.Errorf("%w: %v", ErrTransferNotReversible, err)
+				}
+				return err
+			}
+		}
+
+		remaining := original.Amount - original.ReversedAmount
+		if remaining == 0 {
+			return fmt.Errorf("%w: transfer [%d]", ErrTransferAlreadyReversed, original.ID)
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining {
+			return fmt.Errorf("%w: %d requested, %d left on transfer [%d]", ErrReversalAmountTooLarge, amount, remaining, original.ID)
+		}
+
+		// the share of to_amount is worked out on running totals so the parts add up to to_amount
+		reversedAmount := original.ReversedAmount + amount
+		debitAmount := proportion(original.ToAmount, reversedAmount, original.Amount) -
+			proportion(original.ToAmount, original.ReversedAmount, original.Amount)
+		if debitAmount <= 0 {
+			return fmt.Errorf("%w: %d", ErrAmountTooSmall, amount)
+		}
+
+		exchangeRate, err := inverseExchangeRate(original.ExchangeRate)
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = moveMoney(ctx, q, CreateTransferParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        debitAmount,
+			ToAmount:      amount,
+			ExchangeRate:  exchangeRate,
+			ReversalOf:    pgtype.Int8{Int64: original.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		status := TransferStatusPartiallyReversed
+		if reversedAmount == original.Amount {
+			status = TransferStatusReversed
+		}
+
+		result.OriginalTransfer, err = q.UpdateTransferReversal(ctx, UpdateTransferReversalParams{
+			ID:             original.ID,
+			Status:         status,
+			ReversedAmount: reversedAmount,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// proportion returns total * part / whole, truncated, without overflowing int64 on the way
+func proportion(total int64, part int64, whole int64) int64 {
+	product := new(big.Int).Mul(big.NewInt(total), big.NewInt(part))
+	return product.Quo(product, big.NewInt(whole)).Int64()
+}
+
+// inverseExchangeRate turns the rate of a transfer into the rate for sending it back
+func inverseExchangeRate(rate pgtype.Numeric) (pgtype.Numeric, error) {
+	var inverse pgtype.Numeric
+
+	value, err := rate.Value()
+	if err != nil {
+		return inverse, err
+	}
+
+	parsed, err := fx.NewRate("", "", fmt.Sprint(value))
+	if err != nil {
+		return inverse, err
+	}
+
+	err = inverse.Scan(parsed.Inverse().String())
+	return inverse, err
+}
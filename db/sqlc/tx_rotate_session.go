@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type RotateSessionTxParams struct {
+	// SessionID is the session whose refresh token is being renewed
+	SessionID uuid.UUID
+	// NewSession replaces it, its family is taken from the retired session
+	NewSession CreateSessionParams
+}
+
+type RotateSessionTxResult struct {
+	RetiredSession Session
+	Session        Session
+}
+
+// RotateSessionTx retires a session and creates the one replacing it in the same family, it returns
+// ErrSessionRetired when the session was already retired, e.g. by a concurrent renewal with the same refresh token
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error) {
+	var result RotateSessionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.RetiredSession, err = q.RetireSession(ctx, RetireSessionParams{
+			ID:         arg.SessionID,
+			ReplacedBy: pgtype.UUID{Bytes: arg.NewSession.ID, Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrSessionRetired
+			}
+			return err
+		}
+
+		arg.NewSession.FamilyID = result.RetiredSession.FamilyID
+		result.Session, err = q.CreateSession(ctx, arg.NewSession)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+const (
+	ScheduledTransferStatusActive    = "active"
+	ScheduledTransferStatusPaused    = "paused"
+	ScheduledTransferStatusCompleted = "completed"
+	ScheduledTransferStatusCancelled = "cancelled"
+)
+
+const (
+	ScheduledTransferRunStatusSucceeded = "succeeded"
+	ScheduledTransferRunStatusFailed    = "failed"
+)
+
+type CreateScheduledTransferTxParams struct {
+	CreateScheduledTransferParams
+	AfterCreate func(scheduledTransfer ScheduledTransfer) error
+}
+
+type CreateScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer
+}
+
+// CreateScheduledTransferTx creates a scheduled transfer, AfterCreate queues its first run
+// so the row is rolled back if the run can't be queued
+func (store *SQLStore) CreateScheduledTransferTx(ctx context.Context, arg CreateScheduledTransferTxParams) (CreateScheduledTransferTxResult, error) {
+	var result CreateScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.ScheduledTransfer, err = q.CreateScheduledTransfer(ctx, arg.CreateScheduledTransferParams)
+		if err != nil {
+			return err
+		}
+
+		return arg.AfterCreate(result.ScheduledTransfer)
+	})
+
+	return result, err
+}
+
+// ExecuteScheduledTransferTxParams identifies a single run, RunAt must match the next_run_at the run was queued for
+type ExecuteScheduledTransferTxParams struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	RunAt               time.Time `json:"run_at"`
+}
+
+type ExecuteScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer    `json:"scheduled_transfer"`
+	Run               ScheduledTransferRun `json:"run"`
+	Transfer          *TransferTxResult    `json:"transfer"`
+}
+
+// ExecuteScheduledTransferTx runs a due scheduled transfer and moves it on to its next run
+// A transfer that can't be made is recorded as a failed run with the reason instead of returning an error,
+// so the schedule keeps going. ErrScheduledTransferNotDue is returned for runs that are stale or were already made
+func (store *SQLStore) ExecuteScheduledTransferTx(ctx context.Context, arg ExecuteScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error) {
+	var result ExecuteScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduledTransfer, err := q.GetScheduledTransferForUpdate(ctx, arg.ScheduledTransferID)
+		if err != nil {
+			return err
+		}
+
+		if scheduledTransfer.Status != ScheduledTransferStatusActive {
+			return fmt.Errorf("%w: scheduled transfer [%d] is %s", ErrScheduledTransferNotDue, scheduledTransfer.ID, scheduledTransfer.Status)
+		}
+		if !scheduledTransfer.NextRunAt.Equal(arg.RunAt) {
+			return fmt.Errorf("%w: scheduled transfer [%d] next runs at %s", ErrScheduledTransferNotDue, scheduledTransfer.ID, scheduledTransfer.NextRunAt)
+		}
+
+		runParams := CreateScheduledTransferRunParams{
+			ScheduledTransferID: scheduledTransfer.ID,
+			RunAt:               scheduledTransfer.NextRunAt,
+		}
+
+		runParams.FailureReason, err = transferFailure(ctx, q, scheduledTransfer.FromAccountID, scheduledTransfer.ToAccountID,
+			scheduledTransfer.Amount, scheduledTransfer.CurrencyCode)
+		if err != nil {
+			return err
+		}
+
+		if runParams.FailureReason == "" {
+			// the transfer runs in a savepoint, one that fails part way through is undone and the run is still recorded
+			var transferResult TransferTxResult
+			err := execSavepoint(ctx, q, func(q *Queries) error {
+				var err error
+				transferResult, err = transfer(ctx, q, TransferTxParams{
+					FromAccountID: scheduledTransfer.FromAccountID,
+					ToAccountID:   scheduledTransfer.ToAccountID,
+					Amount:        scheduledTransfer.Amount,
+				})
+				return err
+			})
+
+			runParams.FailureReason, err = transferFailureReason(err)
+			if err != nil {
+				return err
+			}
+			if runParams.FailureReason == "" {
+				result.Transfer = &transferResult
+				runParams.Status = ScheduledTransferRunStatusSucceeded
+				runParams.TransferID = pgtype.Int8{Int64: transferResult.Transfer.ID, Valid: true}
+			}
+		}
+		if runParams.FailureReason != "" {
+			runParams.Status = ScheduledTransferRunStatusFailed
+		}
+
+		result.Run, err = q.CreateScheduledTransferRun(ctx, runParams)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updateParams := UpdateScheduledTransferParams{
+			ID:        scheduledTransfer.ID,
+			LastRunAt: pgtype.Timestamptz{Time: now, Valid: true},
+		}
+
+		// runs missed while the worker was down are skipped rather than made one after the other
+		from := scheduledTransfer.NextRunAt
+		if from.Before(now) {
+			from = now
+		}
+
+		next, ok, err := util.NextScheduleRun(scheduledTransfer.Schedule, from)
+		if err != nil {
+			return err
+		}
+		if ok {
+			updateParams.NextRunAt = pgtype.Timestamptz{Time: next, Valid: true}
+		} else {
+			updateParams.Status = pgtype.Text{String: ScheduledTransferStatusCompleted, Valid: true}
+		}
+
+		result.ScheduledTransfer, err = q.UpdateScheduledTransfer(ctx, updateParams)
+		return err
+	})
+
+	return result, err
+}
+
+// transferFailure returns why a transfer queued earlier can't be made right now, or an empty string if it can
+func transferFailure(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64, amount int64, currencyCode string) (string, error) {
+	fromAccount, err := q.GetAccount(ctx, fromAccountID)
+	if err != nil {
+		return "", err
+	}
+
+	toAccount, err := q.GetAccount(ctx, toAccountID)
+	if err != nil {
+		return "", err
+	}
+
+	available, err := availableBalance(ctx, q, fromAccount)
+	if err != nil {
+		return "", err
+	}
+
+	fee, err := transferFee(ctx, q, fromAccount.CurrencyCode, amount)
+	if errors.Is(err, ErrAmountTooLarge) {
+		return err.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case fromAccount.Status != AccountStatusActive:
+		return fmt.Sprintf("account [%d] is %s", fromAccount.ID, fromAccount.Status), nil
+	case toAccount.Status != AccountStatusActive:
+		return fmt.Sprintf("account [%d] is %s", toAccount.ID, toAccount.Status), nil
+	case toAccount.Kind != AccountKindCustomer:
+		return fmt.Sprintf("account [%d] is a %s account", toAccount.ID, toAccount.Kind), nil
+	case fromAccount.CurrencyCode != currencyCode || toAccount.CurrencyCode != currencyCode:
+		return fmt.Sprintf("account currencies no longer match %s", currencyCode), nil
+	case available < amount+fee:
+		return fmt.Sprintf("account [%d] has insufficient balance", fromAccount.ID), nil
+	}
+
+	return "", nil
+}
+
+// transferFailureReason turns an error from making a transfer queued earlier into the reason it failed.
+// Errors a retry would run into again are recorded as the reason, any other error is returned
+func transferFailureReason(err error) (string, error) {
+	var limitErr *TransferLimitError
+	switch {
+	case err == nil:
+		return "", nil
+	case errors.As(err, &limitErr),
+		errors.Is(err, ErrSameAccount),
+		errors.Is(err, ErrAmountTooLarge),
+		errors.Is(err, ErrInsufficientFunds),
+		errors.Is(err, ErrAccountNotActive),
+		errors.Is(err, ErrCurrencyMismatch),
+		errors.Is(err, ErrCurrencyDisabled),
+		errors.Is(err, ErrNotCustomerAccount):
+		return err.Error(), nil
+	}
+	return "", err
+}
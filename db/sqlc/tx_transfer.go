@@ -1,6 +1,13 @@
 package db
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kelvinator07/golang-bank-microservices/fx"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
 
 const (
 	debit  string = "debit"
@@ -8,10 +15,13 @@ const (
 )
 
 // TransferTxParams contains the iNput parameters of the transfer transaction
+// Amount is in the currency of the from account. ExchangeRate is required when the accounts
+// hold different currencies and is ignored (treated as 1) when it is nil for same currency transfers
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64    `json:"from_account_id"`
+	ToAccountID   int64    `json:"to_account_id"`
+	Amount        int64    `json:"amount"`
+	ExchangeRate  *fx.Rate `json:"exchange_rate"`
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -21,61 +31,262 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// Fee is charged to the from account on top of the amount, FeeEntry is zero when there was no fee
+	Fee      int64 `json:"fee"`
+	FeeEntry Entry `json:"fee_entry"`
+}
+
+// EnteredOverdraft reports whether the transfer took the from account below zero
+func (result TransferTxResult) EnteredOverdraft() bool {
+	previousBalance := result.FromAccount.Balance - result.FromEntry.Amount - result.FeeEntry.Amount
+	return result.FromAccount.Balance < 0 && previousBalance >= 0
 }
 
 // TransferTx performs a money transfer from one account to another
 // It creates a transfer record, add account entries and update accounts balances within a single database transaction
+// The fee for the amount is debited from the from account and credited to the fee revenue account of its currency.
+// ErrInsufficientFunds is returned when the from account can't cover the amount and fee from its balance and overdraft limit,
+// funds on hold don't count. A *TransferLimitError is returned when the amount goes over a limit of the sender's tier,
+// and ErrSameAccount when the from and to account are the same
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
+	return result, err
+}
+
+// transfer moves the money within an open transaction, so other transactions can make a transfer part of their work
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	// the entries of a transfer are checked per account, the ones of a transfer to itself could never balance
+	if arg.FromAccountID == arg.ToAccountID {
+		return result, fmt.Errorf("%w: account [%d]", ErrSameAccount, arg.FromAccountID)
+	}
+
+	// currencies never change, so these reads don't need a lock
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	toAccount, err := q.GetAccount(ctx, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	// system accounts only take part in ledger postings, see DepositTx and WithdrawTx.
+	// No money moves in a disabled currency, whichever side of the transfer it is on
+	for _, account := range []Account{fromAccount, toAccount} {
+		if account.Kind != AccountKindCustomer {
+			return result, fmt.Errorf("%w: account [%d] is a %s account", ErrNotCustomerAccount, account.ID, account.Kind)
+		}
+		if !util.IsEnabledCurrency(account.CurrencyCode) {
+			return result, fmt.Errorf("%w: account [%d] is in %s", ErrCurrencyDisabled, account.ID, account.CurrencyCode)
 		}
+	}
+
+	toAmount, exchangeRate, err := exchangeAmount(arg, fromAccount.CurrencyCode, toAccount.CurrencyCode)
+	if err != nil {
+		return result, err
+	}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+	if err := checkTransferLimits(ctx, q, fromAccount, arg.Amount); err != nil {
+		return result, err
+	}
+
+	fee, err := transferFee(ctx, q, fromAccount.CurrencyCode, arg.Amount)
+	if err != nil {
+		return result, err
+	}
+
+	// the rate is stored with the transfer so it is locked in with the entries it produced
+	return moveMoney(ctx, q, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      toAmount,
+		ExchangeRate:  exchangeRate,
+		Fee:           fee,
+	})
+}
+
+// moveMoney records a transfer whose amounts and fee are already worked out, writes its entries and updates the balances
+func moveMoney(ctx context.Context, q *Queries, arg CreateTransferParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	var err error
+	result.Transfer, err = q.CreateTransfer(ctx, arg)
+	if err != nil {
+		return result, err
+	}
+	result.Fee = arg.Fee
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   arg.FromAccountID,
+		Amount:      -arg.Amount,
+		DebitCredit: debit,
+		TransferID:  pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   arg.ToAccountID,
+		Amount:      arg.ToAmount,
+		DebitCredit: credit,
+		TransferID:  pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if arg.Fee > 0 {
+		result.FeeEntry, err = q.CreateEntry(ctx, CreateEntryParams{
 			AccountID:   arg.FromAccountID,
-			Amount:      -arg.Amount,
+			Amount:      -arg.Fee,
 			DebitCredit: debit,
+			TransferID:  pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
 		})
 		if err != nil {
-			return err
+			return result, err
 		}
+	}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID:   arg.ToAccountID,
-			Amount:      arg.Amount,
-			DebitCredit: credit,
-		})
+	// update account balance
+
+	fromAmount := -(arg.Amount + arg.Fee)
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, fromAmount, arg.ToAccountID, arg.ToAmount)
 		if err != nil {
-			return err
+			return result, err
 		}
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.ToAmount, arg.FromAccountID, fromAmount)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if result.FromAccount.CurrencyCode != result.ToAccount.CurrencyCode {
+		err = exchangeThroughSuspense(ctx, q, result.Transfer, result.FromAccount.CurrencyCode, result.ToAccount.CurrencyCode)
+		if err != nil {
+			return result, err
+		}
+	}
 
-		// update account balance
-
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-			if err != nil {
-				return err
-			}
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-			if err != nil {
-				return err
-			}
+	if arg.Fee > 0 {
+		if err := creditFee(ctx, q, result.Transfer, result.FromAccount.CurrencyCode); err != nil {
+			return result, err
 		}
+	}
+
+	// both rows are locked by now, so the status can't change before commit
+	if result.FromAccount.Status != AccountStatusActive {
+		return result, fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, result.FromAccount.ID, result.FromAccount.Status)
+	}
+	if result.ToAccount.Status != AccountStatusActive {
+		return result, fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, result.ToAccount.ID, result.ToAccount.Status)
+	}
+
+	// system accounts can go below zero, funding does with every deposit
+	if result.FromAccount.Kind != AccountKindCustomer {
+		return result, nil
+	}
+
+	// checked on the locked row, so concurrent transfers can't both spend the same funds
+	available, err := availableBalance(ctx, q, result.FromAccount)
+	if err != nil {
+		return result, err
+	}
+	if available < 0 {
+		return result, fmt.Errorf("%w: account [%d] is short by %d", ErrInsufficientFunds, result.FromAccount.ID, -available)
+	}
+
+	return result, nil
+}
+
+// exchangeThroughSuspense writes the suspense side of a cross currency transfer. The suspense account of the from currency
+// takes in what was sent and the one of the to currency pays out what was received, so neither currency is created or lost
+func exchangeThroughSuspense(ctx context.Context, q *Queries, transfer Transfer, fromCurrency string, toCurrency string) error {
+	fromSuspense, err := systemAccount(ctx, q, AccountKindSuspense, fromCurrency)
+	if err != nil {
+		return err
+	}
+
+	toSuspense, err := systemAccount(ctx, q, AccountKindSuspense, toCurrency)
+	if err != nil {
+		return err
+	}
 
-		return nil
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   fromSuspense.ID,
+		Amount:      transfer.Amount,
+		DebitCredit: credit,
+		TransferID:  pgtype.Int8{Int64: transfer.ID, Valid: true},
 	})
+	if err != nil {
+		return err
+	}
 
-	return result, err
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   toSuspense.ID,
+		Amount:      -transfer.ToAmount,
+		DebitCredit: debit,
+		TransferID:  pgtype.Int8{Int64: transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	// system accounts are locked after the customer accounts and in id order among themselves,
+	// so transfers in opposite directions can't deadlock on them
+	if fromSuspense.ID < toSuspense.ID {
+		_, _, err = addMoney(ctx, q, fromSuspense.ID, transfer.Amount, toSuspense.ID, -transfer.ToAmount)
+	} else {
+		_, _, err = addMoney(ctx, q, toSuspense.ID, -transfer.ToAmount, fromSuspense.ID, transfer.Amount)
+	}
+	return err
+}
+
+// exchangeAmount works out how much the to account is credited and the rate that was applied
+func exchangeAmount(arg TransferTxParams, fromCurrency string, toCurrency string) (int64, pgtype.Numeric, error) {
+	var exchangeRate pgtype.Numeric
+
+	rate := arg.ExchangeRate
+	if rate == nil {
+		if fromCurrency != toCurrency {
+			return 0, exchangeRate, fmt.Errorf("%w: %s to %s needs an exchange rate", ErrCurrencyMismatch, fromCurrency, toCurrency)
+		}
+
+		identity, err := fx.NewRate(fromCurrency, toCurrency, "1")
+		if err != nil {
+			return 0, exchangeRate, err
+		}
+		rate = &identity
+	}
+
+	if rate.From != fromCurrency || rate.To != toCurrency {
+		return 0, exchangeRate, fmt.Errorf("%w: rate is for %s to %s, accounts are %s to %s",
+			ErrCurrencyMismatch, rate.From, rate.To, fromCurrency, toCurrency)
+	}
+
+	toAmount := rate.Convert(arg.Amount)
+	if toAmount <= 0 {
+		return 0, exchangeRate, fmt.Errorf("%w: %d %s", ErrAmountTooSmall, arg.Amount, fromCurrency)
+	}
+
+	if err := exchangeRate.Scan(rate.String()); err != nil {
+		return 0, exchangeRate, err
+	}
+
+	return toAmount, exchangeRate, nil
 }
 
 func addMoney(
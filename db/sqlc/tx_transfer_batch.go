@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	TransferBatchFormatCSV     = "csv"
+	TransferBatchFormatPain001 = "pain001"
+)
+
+const (
+	TransferBatchStatusPending             = "pending"
+	TransferBatchStatusProcessing          = "processing"
+	TransferBatchStatusCompleted           = "completed"
+	TransferBatchStatusCompletedWithErrors = "completed_with_errors"
+	TransferBatchStatusFailed              = "failed"
+)
+
+const (
+	TransferBatchItemStatusPending   = "pending"
+	TransferBatchItemStatusCompleted = "completed"
+	TransferBatchItemStatusFailed    = "failed"
+)
+
+// CreateTransferBatchTxParams holds the batch and its items, the items' BatchID is filled in once the batch exists
+type CreateTransferBatchTxParams struct {
+	CreateTransferBatchParams
+	Items []CreateTransferBatchItemParams
+}
+
+type CreateTransferBatchTxResult struct {
+	Batch TransferBatch       `json:"batch"`
+	Items []TransferBatchItem `json:"items"`
+}
+
+// CreateTransferBatchTx stores a pending batch with all of its items, the caller queues it for processing once
+// it is committed so the worker can't look for it before it exists
+func (store *SQLStore) CreateTransferBatchTx(ctx context.Context, arg CreateTransferBatchTxParams) (CreateTransferBatchTxResult, error) {
+	var result CreateTransferBatchTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Batch, err = q.CreateTransferBatch(ctx, arg.CreateTransferBatchParams)
+		if err != nil {
+			return err
+		}
+
+		result.Items = make([]TransferBatchItem, 0, len(arg.Items))
+		for _, itemParams := range arg.Items {
+			itemParams.BatchID = result.Batch.ID
+
+			item, err := q.CreateTransferBatchItem(ctx, itemParams)
+			if err != nil {
+				return err
+			}
+			result.Items = append(result.Items, item)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+type ExecuteTransferBatchItemTxResult struct {
+	Item     TransferBatchItem `json:"item"`
+	Transfer *TransferTxResult `json:"transfer"`
+}
+
+// ExecuteTransferBatchItemTx makes the transfer for a pending batch item and records the outcome on the item
+// A transfer that can't be made marks the item failed with the reason instead of returning an error,
+// so the rest of the batch keeps going. Items that are no longer pending are returned unchanged
+func (store *SQLStore) ExecuteTransferBatchItemTx(ctx context.Context, itemID int64) (ExecuteTransferBatchItemTxResult, error) {
+	var result ExecuteTransferBatchItemTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Item, err = q.GetTransferBatchItemForUpdate(ctx, itemID)
+		if err != nil {
+			return err
+		}
+
+		if result.Item.Status != TransferBatchItemStatusPending {
+			return nil
+		}
+
+		batch, err := q.GetTransferBatch(ctx, result.Item.BatchID)
+		if err != nil {
+			return err
+		}
+
+		updateParams := UpdateTransferBatchItemParams{
+			ID: result.Item.ID,
+		}
+
+		updateParams.FailureReason, err = transferFailure(ctx, q, batch.FromAccountID, result.Item.ToAccountID,
+			result.Item.Amount, batch.CurrencyCode)
+		if err != nil {
+			return err
+		}
+
+		if updateParams.FailureReason == "" {
+			// the transfer runs in a savepoint, one that fails part way through is undone and the item is still recorded
+			var transferResult TransferTxResult
+			err := execSavepoint(ctx, q, func(q *Queries) error {
+				var err error
+				transferResult, err = transfer(ctx, q, TransferTxParams{
+					FromAccountID: batch.FromAccountID,
+					ToAccountID:   result.Item.ToAccountID,
+					Amount:        result.Item.Amount,
+				})
+				return err
+			})
+
+			updateParams.FailureReason, err = transferFailureReason(err)
+			if err != nil {
+				return err
+			}
+			if updateParams.FailureReason == "" {
+				result.Transfer = &transferResult
+				updateParams.Status = TransferBatchItemStatusCompleted
+				updateParams.TransferID = pgtype.Int8{Int64: transferResult.Transfer.ID, Valid: true}
+			}
+		}
+		if updateParams.FailureReason != "" {
+			updateParams.Status = TransferBatchItemStatusFailed
+		}
+
+		result.Item, err = q.UpdateTransferBatchItem(ctx, updateParams)
+		return err
+	})
+
+	return result, err
+}
+
+// TransferBatchStatus is the status a batch finishes with once none of its items are pending
+func TransferBatchStatus(summary GetTransferBatchSummaryRow) string {
+	switch {
+	case summary.FailedItems == 0:
+		return TransferBatchStatusCompleted
+	case summary.CompletedItems == 0:
+		return TransferBatchStatusFailed
+	}
+	return TransferBatchStatusCompletedWithErrors
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Fee works out what the rule charges on amount. Flat, percentage and combined fees are single rules,
+// a tiered schedule is several rules for the currency with increasing min amounts.
+// It returns ErrAmountTooLarge when the fee, or amount and the fee together, don't fit in an int64
+func (rule FeeRule) Fee(amount int64) (int64, error) {
+	// amount * percentage_bps can overflow on its own, proportion works it out without doing so
+	percentage := proportion(amount, rule.PercentageBps, 10000)
+	if percentage > math.MaxInt64-rule.FlatFee {
+		return 0, fmt.Errorf("%w: fee on %d", ErrAmountTooLarge, amount)
+	}
+
+	fee := rule.FlatFee + percentage
+	if fee > math.MaxInt64-amount {
+		return 0, fmt.Errorf("%w: %d with a fee of %d", ErrAmountTooLarge, amount, fee)
+	}
+
+	return fee, nil
+}
+
+// transferFee returns the fee for sending amount, currencies without fee rules are free
+func transferFee(ctx context.Context, q *Queries, currencyCode string, amount int64) (int64, error) {
+	rule, err := q.GetTransferFeeRule(ctx, GetTransferFeeRuleParams{
+		CurrencyCode: currencyCode,
+		Amount:       amount,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return rule.Fee(amount)
+}
+
+// creditFee writes the fee revenue side of a transfer's fee, the sender side is written with the transfer's own entries
+func creditFee(ctx context.Context, q *Queries, transfer Transfer, currencyCode string) error {
+	feeAccount, err := systemAccount(ctx, q, AccountKindFeeRevenue, currencyCode)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:   feeAccount.ID,
+		Amount:      transfer.Fee,
+		DebitCredit: credit,
+		TransferID:  pgtype.Int8{Int64: transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	// the fee account is always locked last, after the other accounts of the transfer, so it can't deadlock with them
+	_, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     feeAccount.ID,
+		Amount: transfer.Fee,
+	})
+	return err
+}
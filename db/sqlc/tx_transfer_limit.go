@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+const (
+	UserTierStandard = "standard"
+	UserTierPremium  = "premium"
+)
+
+const (
+	TransferLimitPerTransaction = "per_transaction"
+	TransferLimitDaily          = "daily"
+	TransferLimitMonthly        = "monthly"
+)
+
+// TransferLimitError names the tier limit a transfer would go over, it wraps ErrTransferLimitExceeded
+type TransferLimitError struct {
+	Limit        string `json:"limit"`
+	Tier         string `json:"tier"`
+	CurrencyCode string `json:"currency_code"`
+	Max          int64  `json:"max"`
+	Remaining    int64  `json:"remaining"`
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("%s: %s %s limit is %s, %s remaining", ErrTransferLimitExceeded, e.Tier, e.Limit,
+		util.NewMoney(e.Max, e.CurrencyCode).Display(), util.NewMoney(e.Remaining, e.CurrencyCode).Display())
+}
+
+func (e *TransferLimitError) Unwrap() error {
+	return ErrTransferLimitExceeded
+}
+
+// RemainingTransferLimit is what a user can still send in one currency before a limit resets
+type RemainingTransferLimit struct {
+	CurrencyCode     string    `json:"currency_code"`
+	PerTransaction   int64     `json:"per_transaction"`
+	Daily            int64     `json:"daily"`
+	DailyRemaining   int64     `json:"daily_remaining"`
+	DailyResetsAt    time.Time `json:"daily_resets_at"`
+	Monthly          int64     `json:"monthly"`
+	MonthlyRemaining int64     `json:"monthly_remaining"`
+	MonthlyResetsAt  time.Time `json:"monthly_resets_at"`
+}
+
+type GetRemainingTransferLimitsResult struct {
+	Tier   string                   `json:"tier"`
+	Limits []RemainingTransferLimit `json:"limits"`
+}
+
+// GetRemainingTransferLimits returns the user's tier limits for every currency with what is left of them
+func (store *SQLStore) GetRemainingTransferLimits(ctx context.Context, userID int64) (GetRemainingTransferLimitsResult, error) {
+	var result GetRemainingTransferLimitsResult
+
+	user, err := store.GetUser(ctx, userID)
+	if err != nil {
+		return result, err
+	}
+	result.Tier = user.Tier
+
+	limits, err := store.ListTransferLimits(ctx, user.Tier)
+	if err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	result.Limits = make([]RemainingTransferLimit, 0, len(limits))
+	for _, limit := range limits {
+		remaining, err := remainingTransferLimit(ctx, store.Queries, userID, limit, now)
+		if err != nil {
+			return result, err
+		}
+		result.Limits = append(result.Limits, remaining)
+	}
+
+	return result, nil
+}
+
+// checkTransferLimits returns a TransferLimitError when amount would take the owner of fromAccount over a limit of their tier.
+// It locks the user row, so concurrent transfers by the same user are counted one after the other.
+// Currencies without limits configured for the tier and system accounts aren't limited
+func checkTransferLimits(ctx context.Context, q *Queries, fromAccount Account, amount int64) error {
+	if !fromAccount.UserID.Valid {
+		return nil
+	}
+
+	user, err := q.GetUserForUpdate(ctx, fromAccount.UserID.Int64)
+	if err != nil {
+		return err
+	}
+
+	limit, err := q.GetTransferLimit(ctx, GetTransferLimitParams{
+		Tier:         user.Tier,
+		CurrencyCode: fromAccount.CurrencyCode,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	remaining, err := remainingTransferLimit(ctx, q, user.ID, limit, time.Now())
+	if err != nil {
+		return err
+	}
+
+	limitErr := &TransferLimitError{Tier: limit.Tier, CurrencyCode: limit.CurrencyCode}
+	switch {
+	case amount > limit.PerTransaction:
+		limitErr.Limit, limitErr.Max, limitErr.Remaining = TransferLimitPerTransaction, limit.PerTransaction, limit.PerTransaction
+	case amount > remaining.DailyRemaining:
+		limitErr.Limit, limitErr.Max, limitErr.Remaining = TransferLimitDaily, limit.Daily, remaining.DailyRemaining
+	case amount > remaining.MonthlyRemaining:
+		limitErr.Limit, limitErr.Max, limitErr.Remaining = TransferLimitMonthly, limit.Monthly, remaining.MonthlyRemaining
+	default:
+		return nil
+	}
+
+	return limitErr
+}
+
+// remainingTransferLimit works out the daily and monthly allowance left at now, both windows follow the UTC calendar
+func remainingTransferLimit(ctx context.Context, q *Queries, userID int64, limit TransferLimit, now time.Time) (RemainingTransferLimit, error) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	result := RemainingTransferLimit{
+		CurrencyCode:    limit.CurrencyCode,
+		PerTransaction:  limit.PerTransaction,
+		Daily:           limit.Daily,
+		DailyResetsAt:   dayStart.AddDate(0, 0, 1),
+		Monthly:         limit.Monthly,
+		MonthlyResetsAt: monthStart.AddDate(0, 1, 0),
+	}
+
+	dailyTotal, err := q.GetUserTransferTotal(ctx, GetUserTransferTotalParams{
+		UserID:       userID,
+		CurrencyCode: limit.CurrencyCode,
+		Since:        dayStart,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	monthlyTotal, err := q.GetUserTransferTotal(ctx, GetUserTransferTotalParams{
+		UserID:       userID,
+		CurrencyCode: limit.CurrencyCode,
+		Since:        monthStart,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// totals can be over a limit that was lowered after the money was sent
+	if dailyTotal < limit.Daily {
+		result.DailyRemaining = limit.Daily - dailyTotal
+	}
+	if monthlyTotal < limit.Monthly {
+		result.MonthlyRemaining = limit.Monthly - monthlyTotal
+	}
+
+	return result, nil
+}
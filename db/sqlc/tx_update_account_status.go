@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	AccountStatusInactive string = "inactive"
+	AccountStatusActive   string = "active"
+	AccountStatusFrozen   string = "frozen"
+	AccountStatusClosed   string = "closed"
+)
+
+// accountStatusTransitions lists the statuses an account can move to from its current status
+var accountStatusTransitions = map[string][]string{
+	AccountStatusInactive: {AccountStatusActive, AccountStatusClosed},
+	AccountStatusActive:   {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen:   {AccountStatusActive},
+}
+
+// CanTransitionAccountStatus reports whether an account can move from one status to another
+func CanTransitionAccountStatus(from string, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+type UpdateAccountStatusTxParams struct {
+	AccountID int64
+	Status    string
+	ChangedBy int64
+	Reason    string
+}
+
+type UpdateAccountStatusTxResult struct {
+	Account       Account              `json:"account"`
+	StatusHistory AccountStatusHistory `json:"status_history"`
+}
+
+// UpdateAccountStatusTx moves an account to a new status and records the change in the status history
+// The account row is locked so concurrent transfers see either the old or the new status, never both
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error) {
+	var result UpdateAccountStatusTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if !CanTransitionAccountStatus(account.Status, arg.Status) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, account.Status, arg.Status)
+		}
+
+		if arg.Status == AccountStatusClosed && account.Balance != 0 {
+			return fmt.Errorf("%w: account [%d] balance is %d", ErrAccountBalanceNotZero, account.ID, account.Balance)
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     arg.AccountID,
+			Status: arg.Status,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.StatusHistory, err = q.CreateAccountStatusHistory(ctx, CreateAccountStatusHistoryParams{
+			AccountID:  arg.AccountID,
+			FromStatus: account.Status,
+			ToStatus:   arg.Status,
+			ChangedBy:  arg.ChangedBy,
+			Reason:     arg.Reason,
+		})
+
+		return err
+	})
+
+	return result, err
+}
@@ -21,7 +21,7 @@ INSERT INTO users (
   email
 ) VALUES (
   $1, $2, $3, $4, $5, $6
-) RETURNING id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified
+) RETURNING id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier
 `
 
 type CreateUserParams struct {
@@ -54,6 +54,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
@@ -69,7 +71,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 }
 
 const getAllUsers = `-- name: GetAllUsers :many
-SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified FROM users
+SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier FROM users
 WHERE id > $1
 ORDER BY id
 LIMIT $2
@@ -100,6 +102,8 @@ func (q *Queries) GetAllUsers(ctx context.Context, arg GetAllUsersParams) ([]Use
 			&i.PasswordChangedAt,
 			&i.CreatedAt,
 			&i.IsEmailVerified,
+			&i.Role,
+			&i.Tier,
 		); err != nil {
 			return nil, err
 		}
@@ -112,7 +116,7 @@ func (q *Queries) GetAllUsers(ctx context.Context, arg GetAllUsersParams) ([]Use
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified FROM users
+SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -130,12 +134,14 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified FROM users
+SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier FROM users
 WHERE email = $1 LIMIT 1
 `
 
@@ -153,12 +159,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
 
 const getUserForUpdate = `-- name: GetUserForUpdate :one
-SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified FROM users
+SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier FROM users
 WHERE id = $1 LIMIT 1 
 FOR NO KEY UPDATE
 `
@@ -177,12 +185,14 @@ func (q *Queries) GetUserForUpdate(ctx context.Context, id int64) (User, error)
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified FROM users
+SELECT id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier FROM users
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -213,6 +223,8 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.PasswordChangedAt,
 			&i.CreatedAt,
 			&i.IsEmailVerified,
+			&i.Role,
+			&i.Tier,
 		); err != nil {
 			return nil, err
 		}
@@ -236,7 +248,7 @@ SET
   is_email_verified = COALESCE($7, is_email_verified)
 WHERE
   email = $6
-RETURNING id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified
+RETURNING id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier
 `
 
 type UpdateUserParams struct {
@@ -271,6 +283,72 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE users
+SET email = $2, is_email_verified = false
+WHERE id = $1
+RETURNING id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier
+`
+
+type UpdateUserEmailParams struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.AccountName,
+		&i.HashedPassword,
+		&i.Address,
+		&i.Gender,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Tier,
+	)
+	return i, err
+}
+
+const updateUserTier = `-- name: UpdateUserTier :one
+UPDATE users
+SET tier = $2
+WHERE id = $1
+RETURNING id, account_name, hashed_password, address, gender, phone_number, email, password_changed_at, created_at, is_email_verified, role, tier
+`
+
+type UpdateUserTierParams struct {
+	ID   int64  `json:"id"`
+	Tier string `json:"tier"`
+}
+
+func (q *Queries) UpdateUserTier(ctx context.Context, arg UpdateUserTierParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserTier, arg.ID, arg.Tier)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.AccountName,
+		&i.HashedPassword,
+		&i.Address,
+		&i.Gender,
+		&i.PhoneNumber,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Tier,
 	)
 	return i, err
 }
@@ -56,6 +56,17 @@ func (q *Queries) GetVerifyEmail(ctx context.Context, id int64) (VerifyEmail, er
 	return i, err
 }
 
+const invalidateVerifyEmails = `-- name: InvalidateVerifyEmails :exec
+UPDATE verify_emails
+SET is_used = TRUE
+WHERE email = $1 AND is_used = FALSE
+`
+
+func (q *Queries) InvalidateVerifyEmails(ctx context.Context, email string) error {
+	_, err := q.db.Exec(ctx, invalidateVerifyEmails, email)
+	return err
+}
+
 const updateVerifyEmail = `-- name: UpdateVerifyEmail :one
 UPDATE verify_emails
 SET is_used = TRUE
@@ -0,0 +1,77 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type RateProvider interface {
+	GetRate(ctx context.Context, from string, to string) (Rate, error)
+}
+
+// StaticRateProvider serves a fixed set of rates, mainly for local development and tests
+type StaticRateProvider struct {
+	rates map[string]Rate
+}
+
+// NewStaticRateProvider builds a provider from rates keyed by "FROM/TO", e.g. {"USD/NGN": "1500.25"}.
+// The inverse of every rate is served too unless it is listed explicitly.
+func NewStaticRateProvider(rates map[string]string) (RateProvider, error) {
+	provider := &StaticRateProvider{rates: make(map[string]Rate)}
+
+	for pair, value := range rates {
+		from, to, ok := strings.Cut(pair, "/")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("%w: pair %q must look like FROM/TO", ErrInvalidRate, pair)
+		}
+
+		rate, err := NewRate(from, to, value)
+		if err != nil {
+			return nil, err
+		}
+		provider.rates[pair] = rate
+	}
+
+	for _, rate := range provider.rates {
+		inverse := rate.Inverse()
+		if _, ok := provider.rates[pairKey(inverse.From, inverse.To)]; !ok {
+			provider.rates[pairKey(inverse.From, inverse.To)] = inverse
+		}
+	}
+
+	return provider, nil
+}
+
+// NewFileRateProvider loads static rates from a JSON file, e.g. {"USD/NGN": "1500.25", "EUR/USD": "1.08"}
+func NewFileRateProvider(path string) (RateProvider, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rates file %s: %w", path, err)
+	}
+
+	var rates map[string]string
+	if err = json.Unmarshal(file, &rates); err != nil {
+		return nil, fmt.Errorf("failed to parse rates file %s: %w", path, err)
+	}
+
+	return NewStaticRateProvider(rates)
+}
+
+func (provider *StaticRateProvider) GetRate(ctx context.Context, from string, to string) (Rate, error) {
+	if from == to {
+		return NewRate(from, to, "1")
+	}
+
+	rate, ok := provider.rates[pairKey(from, to)]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+	}
+	return rate, nil
+}
+
+func pairKey(from string, to string) string {
+	return from + "/" + to
+}
@@ -0,0 +1,54 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticRateProvider(t *testing.T) {
+	provider, err := NewStaticRateProvider(map[string]string{"USD/NGN": "1500.25"})
+	assert.NoError(t, err)
+
+	rate, err := provider.GetRate(context.Background(), "USD", "NGN")
+	assert.NoError(t, err)
+	assert.Equal(t, "1500.2500000000", rate.String())
+	assert.Equal(t, int64(150025), rate.Convert(100))
+
+	// inverse rates are derived and truncate towards zero
+	rate, err = provider.GetRate(context.Background(), "NGN", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0006665556", rate.String())
+	assert.Equal(t, int64(99), rate.Convert(150000))
+
+	rate, err = provider.GetRate(context.Background(), "EUR", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), rate.Convert(42))
+
+	_, err = provider.GetRate(context.Background(), "EUR", "NGN")
+	assert.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestStaticRateProviderInvalidRate(t *testing.T) {
+	_, err := NewStaticRateProvider(map[string]string{"USD/NGN": "-1"})
+	assert.ErrorIs(t, err, ErrInvalidRate)
+
+	_, err = NewStaticRateProvider(map[string]string{"USDNGN": "1500"})
+	assert.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestFileRateProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{"EUR/USD": "1.08"}`), 0o600)
+	assert.NoError(t, err)
+
+	provider, err := NewFileRateProvider(path)
+	assert.NoError(t, err)
+
+	rate, err := provider.GetRate(context.Background(), "EUR", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(108), rate.Convert(100))
+}
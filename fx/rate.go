@@ -0,0 +1,73 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+// RatePrecision is the number of decimal places a rate is kept to, it matches transfers.exchange_rate
+const RatePrecision = 10
+
+var (
+	ErrRateNotFound = errors.New("exchange rate not found")
+	ErrInvalidRate  = errors.New("invalid exchange rate")
+)
+
+// Rate is the amount of To currency one unit of From currency buys
+type Rate struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Value *big.Rat `json:"-"`
+}
+
+// NewRate parses a decimal rate such as "1500.25", rounding it to RatePrecision decimal places
+func NewRate(from string, to string, value string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || r.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("%w: %s/%s %q", ErrInvalidRate, from, to, value)
+	}
+
+	return Rate{From: from, To: to, Value: round(r)}, nil
+}
+
+// Convert turns an amount in minor units of From currency into minor units of To currency,
+// allowing for the currencies having a different number of decimal places.
+// Fractions of the smallest unit are truncated.
+func (r Rate) Convert(amount int64) int64 {
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), r.Value)
+
+	scale := util.CurrencyFor(r.To).MinorUnits - util.CurrencyFor(r.From).MinorUnits
+	factor := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(scale))), nil))
+	if scale < 0 {
+		factor.Inv(factor)
+	}
+	converted.Mul(converted, factor)
+
+	return new(big.Int).Quo(converted.Num(), converted.Denom()).Int64()
+}
+
+// Inverse returns the rate for the opposite direction
+func (r Rate) Inverse() Rate {
+	return Rate{From: r.To, To: r.From, Value: round(new(big.Rat).Inv(r.Value))}
+}
+
+// String renders the rate as a decimal with RatePrecision places
+func (r Rate) String() string {
+	return r.Value.FloatString(RatePrecision)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func round(r *big.Rat) *big.Rat {
+	rounded, _ := new(big.Rat).SetString(r.FloatString(RatePrecision))
+	return rounded
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/hibiken/asynq"
@@ -38,13 +40,25 @@ func main() {
 
 	store := db.NewStore(connPool)
 
+	err = db.LoadCurrencies(context.Background(), store)
+	if err != nil {
+		log.Fatal("Cannot load currencies: ", err)
+	}
+
+	if len(os.Args) > 1 {
+		runCommand(store, os.Args[1:])
+		return
+	}
+
 	redisOpt := asynq.RedisClientOpt{
 		Addr: config.RedisAddress,
 	}
 
 	taskDistributor := worker.NewRedisTaskDistributor(redisOpt)
 
-	go runTaskProcessor(config, redisOpt, store)
+	go refreshCurrencies(store, config.CurrencyRefreshInterval)
+	go runTaskProcessor(config, redisOpt, store, taskDistributor)
+	runTaskScheduler(config, redisOpt)
 
 	server, err := api.NewServer(config, store, taskDistributor)
 	if err != nil {
@@ -57,10 +71,10 @@ func main() {
 	}
 }
 
-func runTaskProcessor(config util.Env, redisOpt asynq.RedisClientOpt, store db.Store) {
+func runTaskProcessor(config util.Env, redisOpt asynq.RedisClientOpt, store db.Store, taskDistributor worker.TaskDistributor) {
 	mailer := mail.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
 
-	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, mailer)
+	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, mailer, taskDistributor)
 	log.Println("Starting task processor")
 	err := taskProcessor.Start()
 	if err != nil {
@@ -68,6 +82,73 @@ func runTaskProcessor(config util.Env, redisOpt asynq.RedisClientOpt, store db.S
 	}
 }
 
+func runTaskScheduler(config util.Env, redisOpt asynq.RedisClientOpt) {
+	taskScheduler, err := worker.NewRedisTaskScheduler(redisOpt, config)
+	if err != nil {
+		log.Fatal("Failed to create task scheduler: ", err)
+	}
+
+	log.Println("Starting task scheduler")
+	err = taskScheduler.Start()
+	if err != nil {
+		log.Fatal("Failed to start task scheduler: ", err)
+	}
+}
+
+// refreshCurrencies reloads the currency registry every interval, so changes an admin makes through
+// another instance of the server are picked up
+func refreshCurrencies(store db.Store, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := db.LoadCurrencies(context.Background(), store); err != nil {
+			log.Println("Failed to refresh currencies: ", err)
+		}
+	}
+}
+
+// runCommand runs a one-off subcommand instead of the server
+func runCommand(store db.Store, args []string) {
+	switch args[0] {
+	case "reconcile":
+		runReconciliation(store)
+	default:
+		log.Fatalf("unknown command %q, available commands: reconcile", args[0])
+	}
+}
+
+// runReconciliation reconciles the ledger and prints each discrepancy, it exits with status 1 when there are any
+func runReconciliation(store db.Store) {
+	result, err := store.ReconcileLedgerTx(context.Background(), db.ReconcileLedgerTxParams{
+		TriggeredBy: db.ReconciliationTriggeredByCLI,
+	})
+	if err != nil {
+		log.Fatal("Cannot reconcile ledger: ", err)
+	}
+
+	fmt.Printf("reconciliation run %d: checked %d accounts and %d transfers, found %d discrepancies\n",
+		result.Run.ID, result.Run.AccountsChecked, result.Run.TransfersChecked, result.Run.Discrepancies)
+
+	for _, discrepancy := range result.Discrepancies {
+		if discrepancy.TransferID.Valid {
+			fmt.Printf("%s: account %d, transfer %d, expected %d, actual %d\n",
+				discrepancy.Kind, discrepancy.AccountID, discrepancy.TransferID.Int64, discrepancy.Expected, discrepancy.Actual)
+			continue
+		}
+		fmt.Printf("%s: account %d, expected %d, actual %d\n",
+			discrepancy.Kind, discrepancy.AccountID, discrepancy.Expected, discrepancy.Actual)
+	}
+
+	if result.Run.Discrepancies > 0 {
+		os.Exit(1)
+	}
+}
+
 func runDBMigration(migrationURL string, dbSource string) {
 	migration, err := migrate.New(migrationURL, dbSource)
 	if err != nil {
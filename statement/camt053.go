@@ -0,0 +1,199 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// the elements below follow the order the camt.053.001.02 schema requires, only the ones the bank fills are declared
+type camt053Document struct {
+	XMLName xml.Name      `xml:"Document"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Stmt    camt053Report `xml:"BkToCstmrStmt"`
+}
+
+type camt053Report struct {
+	GrpHdr camt053GroupHeader `xml:"GrpHdr"`
+	Stmt   camt053Statement   `xml:"Stmt"`
+}
+
+type camt053GroupHeader struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camt053Statement struct {
+	ID        string           `xml:"Id"`
+	CreDtTm   string           `xml:"CreDtTm"`
+	FrToDt    camt053Period    `xml:"FrToDt"`
+	Acct      camt053Account   `xml:"Acct"`
+	Bal       []camt053Balance `xml:"Bal"`
+	TxsSummry camt053Summary   `xml:"TxsSummry"`
+	Ntry      []camt053Entry   `xml:"Ntry"`
+}
+
+type camt053Period struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camt053Account struct {
+	ID  string `xml:"Id>Othr>Id"`
+	Ccy string `xml:"Ccy"`
+}
+
+type camt053Amount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camt053Balance struct {
+	Code      string        `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camt053Amount `xml:"Amt"`
+	CdtDbtInd string        `xml:"CdtDbtInd"`
+	Dt        string        `xml:"Dt>Dt"`
+}
+
+type camt053Summary struct {
+	TtlNtries    camt053EntryTotal `xml:"TtlNtries"`
+	TtlCdtNtries camt053EntryTotal `xml:"TtlCdtNtries"`
+	TtlDbtNtries camt053EntryTotal `xml:"TtlDbtNtries"`
+}
+
+type camt053EntryTotal struct {
+	NbOfNtries int    `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+type camt053Entry struct {
+	NtryRef      string        `xml:"NtryRef"`
+	Amt          camt053Amount `xml:"Amt"`
+	CdtDbtInd    string        `xml:"CdtDbtInd"`
+	Sts          string        `xml:"Sts"`
+	BookgDt      string        `xml:"BookgDt>DtTm"`
+	ValDt        string        `xml:"ValDt>DtTm"`
+	BkTxCd       string        `xml:"BkTxCd>Prtry>Cd"`
+	EndToEndID   string        `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	AddtlNtryInf string        `xml:"AddtlNtryInf"`
+}
+
+// WriteCamt053 writes the statement as an ISO 20022 camt.053 bank to customer statement created at createdAt
+func (statement Statement) WriteCamt053(w io.Writer, createdAt time.Time) error {
+	id := fmt.Sprintf("STMT-%s-%s", statement.AccountNumber, statement.From.UTC().Format("20060102"))
+	created := createdAt.UTC().Format(time.RFC3339)
+
+	document := camt053Document{
+		Xmlns: camt053Namespace,
+		Stmt: camt053Report{
+			GrpHdr: camt053GroupHeader{MsgID: id, CreDtTm: created},
+			Stmt: camt053Statement{
+				ID:      id,
+				CreDtTm: created,
+				FrToDt: camt053Period{
+					FrDtTm: statement.From.UTC().Format(time.RFC3339),
+					ToDtTm: statement.To.UTC().Format(time.RFC3339),
+				},
+				Acct: camt053Account{
+					ID:  statement.AccountNumber,
+					Ccy: statement.CurrencyCode,
+				},
+				Bal: []camt053Balance{
+					statement.camt053Balance("OPBD", statement.OpeningBalance, statement.From),
+					statement.camt053Balance("CLBD", statement.ClosingBalance, statement.lastDay()),
+				},
+				TxsSummry: statement.camt053Summary(),
+				Ntry:      make([]camt053Entry, 0, len(statement.Lines)),
+			},
+		},
+	}
+
+	for _, line := range statement.Lines {
+		endToEndID := "NOTPROVIDED"
+		if line.TransferID != 0 {
+			endToEndID = strconv.FormatInt(line.TransferID, 10)
+		}
+		booked := line.Date.UTC().Format(time.RFC3339)
+
+		document.Stmt.Stmt.Ntry = append(document.Stmt.Stmt.Ntry, camt053Entry{
+			NtryRef:      strconv.FormatInt(line.EntryID, 10),
+			Amt:          camt053Amount{Ccy: statement.CurrencyCode, Value: statement.formatDecimal(abs(line.Amount), ".")},
+			CdtDbtInd:    creditDebitIndicator(line.Amount),
+			Sts:          "BOOK",
+			BookgDt:      booked,
+			ValDt:        booked,
+			BkTxCd:       "TRF",
+			EndToEndID:   endToEndID,
+			AddtlNtryInf: line.Description,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (statement Statement) camt053Balance(code string, balance int64, date time.Time) camt053Balance {
+	return camt053Balance{
+		Code:      code,
+		Amt:       camt053Amount{Ccy: statement.CurrencyCode, Value: statement.formatDecimal(abs(balance), ".")},
+		CdtDbtInd: creditDebitIndicator(balance),
+		Dt:        date.UTC().Format("2006-01-02"),
+	}
+}
+
+func (statement Statement) camt053Summary() camt053Summary {
+	var credits, debits int
+	for _, line := range statement.Lines {
+		if line.Amount < 0 {
+			debits++
+		} else {
+			credits++
+		}
+	}
+
+	return camt053Summary{
+		TtlNtries:    camt053EntryTotal{NbOfNtries: len(statement.Lines), Sum: statement.formatDecimal(statement.TotalCredits+statement.TotalDebits, ".")},
+		TtlCdtNtries: camt053EntryTotal{NbOfNtries: credits, Sum: statement.formatDecimal(statement.TotalCredits, ".")},
+		TtlDbtNtries: camt053EntryTotal{NbOfNtries: debits, Sum: statement.formatDecimal(statement.TotalDebits, ".")},
+	}
+}
+
+// lastDay is the last day the statement covers, To itself is excluded
+func (statement Statement) lastDay() time.Time {
+	return statement.To.Add(-time.Nanosecond)
+}
+
+func creditDebitIndicator(amount int64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// formatDecimal renders an amount of minor units with the currency's decimal places and the given separator, e.g. 1234 as 12,34
+func (statement Statement) formatDecimal(amount int64, separator string) string {
+	return util.NewMoney(amount, statement.CurrencyCode).Decimal(separator)
+}
+
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
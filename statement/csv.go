@@ -0,0 +1,46 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{"date", "entry_id", "transfer_id", "description", "amount", "balance"}
+
+// WriteCSV writes the statement as CSV, the opening and closing balances are the first and last rows
+func (statement Statement) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	records := [][]string{
+		csvHeader,
+		{statement.From.UTC().Format(time.RFC3339), "", "", "Opening balance", "", statement.formatAmount(statement.OpeningBalance)},
+	}
+
+	for _, line := range statement.Lines {
+		transferID := ""
+		if line.TransferID != 0 {
+			transferID = strconv.FormatInt(line.TransferID, 10)
+		}
+
+		records = append(records, []string{
+			line.Date.UTC().Format(time.RFC3339),
+			strconv.FormatInt(line.EntryID, 10),
+			transferID,
+			line.Description,
+			statement.formatAmount(line.Amount),
+			statement.formatAmount(line.Balance),
+		})
+	}
+
+	records = append(records,
+		[]string{statement.To.UTC().Format(time.RFC3339), "", "", "Closing balance", "", statement.formatAmount(statement.ClosingBalance)})
+
+	return writer.WriteAll(records)
+}
+
+// formatAmount renders an amount of minor units as a decimal in the statement's currency, e.g. -1234 as -12.34
+func (statement Statement) formatAmount(amount int64) string {
+	return statement.formatDecimal(amount, ".")
+}
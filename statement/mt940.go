@@ -0,0 +1,75 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// mt940 lines end in CRLF and :86: narratives are cut to 65 characters, as the SWIFT character set requires
+const (
+	mt940LineEnd        = "\r\n"
+	mt940NarrativeWidth = 65
+)
+
+// WriteMT940 writes the statement as a SWIFT MT940 customer statement message
+func (statement Statement) WriteMT940(w io.Writer) error {
+	var message strings.Builder
+
+	field := func(tag string, value string) {
+		fmt.Fprintf(&message, ":%s:%s%s", tag, value, mt940LineEnd)
+	}
+
+	field("20", fmt.Sprintf("STMT%s", statement.From.UTC().Format("060102")))
+	field("25", statement.AccountNumber)
+	field("28C", "1/1")
+	field("60F", statement.mt940Balance(statement.OpeningBalance, statement.From))
+
+	for _, line := range statement.Lines {
+		reference := "NONREF"
+		if line.TransferID != 0 {
+			reference = fmt.Sprintf("%d", line.TransferID)
+		}
+
+		date := line.Date.UTC()
+		field("61", fmt.Sprintf("%s%s%s%sNTRF%s//%d",
+			date.Format("060102"), date.Format("0102"), mt940Mark(line.Amount), statement.formatDecimal(abs(line.Amount), ","), reference, line.EntryID))
+		field("86", mt940Narrative(line.Description))
+	}
+
+	field("62F", statement.mt940Balance(statement.ClosingBalance, statement.lastDay()))
+	message.WriteString("-" + mt940LineEnd)
+
+	_, err := io.WriteString(w, message.String())
+	return err
+}
+
+func (statement Statement) mt940Balance(balance int64, date time.Time) string {
+	return fmt.Sprintf("%s%s%s%s", mt940Mark(balance), date.UTC().Format("060102"), statement.CurrencyCode, statement.formatDecimal(abs(balance), ","))
+}
+
+func mt940Mark(amount int64) string {
+	if amount < 0 {
+		return "D"
+	}
+	return "C"
+}
+
+// mt940Narrative keeps to the SWIFT x character set, anything else becomes a space
+func mt940Narrative(text string) string {
+	narrative := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("/-?:().,'+ ", r):
+			return r
+		}
+		return ' '
+	}, text)
+
+	if len(narrative) > mt940NarrativeWidth {
+		narrative = narrative[:mt940NarrativeWidth]
+	}
+	return narrative
+}
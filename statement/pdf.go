@@ -0,0 +1,167 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the statement is laid out in Courier on A4 pages, a monospaced font keeps the columns aligned
+// without measuring text, which lets the PDF be written by hand
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 9
+	pdfLeading      = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+const pdfRowFormat = "%-16s  %-36s  %14s  %14s"
+
+// WritePDF writes the statement as a PDF document
+func (statement Statement) WritePDF(w io.Writer) error {
+	pages := paginate(statement.pdfTitle(), statement.pdfRows())
+
+	doc := &pdfDocument{}
+	doc.addObject("<< /Type /Catalog /Pages 2 0 R >>")
+
+	// each page takes two objects after the catalog, the page tree and the font
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	doc.addObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	doc.addObject("<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+
+	for i, page := range pages {
+		doc.addObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+
+		content := pdfContent(page)
+		doc.addObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	_, err := w.Write(doc.bytes())
+	return err
+}
+
+func (statement Statement) pdfTitle() []string {
+	return []string{
+		"Account statement",
+		"",
+		fmt.Sprintf("Account number:  %s", statement.AccountNumber),
+		fmt.Sprintf("Currency:        %s", statement.CurrencyCode),
+		fmt.Sprintf("Period:          %s to %s", statement.From.UTC().Format("2006-01-02 15:04"), statement.To.UTC().Format("2006-01-02 15:04")),
+		"",
+		fmt.Sprintf("Opening balance: %s", statement.formatAmount(statement.OpeningBalance)),
+		fmt.Sprintf("Total debits:    %s", statement.formatAmount(statement.TotalDebits)),
+		fmt.Sprintf("Total credits:   %s", statement.formatAmount(statement.TotalCredits)),
+		fmt.Sprintf("Closing balance: %s", statement.formatAmount(statement.ClosingBalance)),
+		"",
+	}
+}
+
+func (statement Statement) pdfRows() []string {
+	rows := make([]string, 0, len(statement.Lines)+2)
+
+	rows = append(rows, fmt.Sprintf(pdfRowFormat, statement.From.UTC().Format("2006-01-02 15:04"), "Opening balance", "", statement.formatAmount(statement.OpeningBalance)))
+	for _, line := range statement.Lines {
+		description := line.Description
+		if len(description) > 36 {
+			description = description[:36]
+		}
+		rows = append(rows, fmt.Sprintf(pdfRowFormat,
+			line.Date.UTC().Format("2006-01-02 15:04"), description, statement.formatAmount(line.Amount), statement.formatAmount(line.Balance)))
+	}
+	rows = append(rows, fmt.Sprintf(pdfRowFormat, statement.To.UTC().Format("2006-01-02 15:04"), "Closing balance", "", statement.formatAmount(statement.ClosingBalance)))
+
+	return rows
+}
+
+// paginate puts the title on the first page and repeats the column headings on every page
+func paginate(title []string, rows []string) [][]string {
+	heading := []string{
+		fmt.Sprintf(pdfRowFormat, "Date", "Description", "Amount", "Balance"),
+		strings.Repeat("-", len(fmt.Sprintf(pdfRowFormat, "", "", "", ""))),
+	}
+
+	var pages [][]string
+	page := append(append([]string{}, title...), heading...)
+
+	for _, row := range rows {
+		if len(page) == pdfLinesPerPage {
+			pages = append(pages, page)
+			page = append([]string{}, heading...)
+		}
+		page = append(page, row)
+	}
+
+	return append(pages, page)
+}
+
+// pdfContent draws the lines of a page from the top left corner down
+func pdfContent(lines []string) string {
+	var content strings.Builder
+
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+	for i, line := range lines {
+		if i > 0 {
+			content.WriteString("T*\n")
+		}
+		fmt.Fprintf(&content, "(%s) Tj\n", pdfEscape(line))
+	}
+	content.WriteString("ET")
+
+	return content.String()
+}
+
+// pdfEscape makes text safe for a PDF string literal, the standard fonts only cover ASCII here
+func pdfEscape(text string) string {
+	var escaped strings.Builder
+
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < ' ' || r > '~':
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+
+	return escaped.String()
+}
+
+// pdfDocument numbers objects in the order they are added and records where each starts for the xref table
+type pdfDocument struct {
+	body    bytes.Buffer
+	offsets []int
+}
+
+const pdfHeader = "%PDF-1.4\n"
+
+func (doc *pdfDocument) addObject(object string) {
+	doc.offsets = append(doc.offsets, len(pdfHeader)+doc.body.Len())
+	fmt.Fprintf(&doc.body, "%d 0 obj\n%s\nendobj\n", len(doc.offsets), object)
+}
+
+func (doc *pdfDocument) bytes() []byte {
+	var out bytes.Buffer
+
+	out.WriteString(pdfHeader)
+	out.Write(doc.body.Bytes())
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(doc.offsets)+1)
+	for _, offset := range doc.offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(doc.offsets)+1, xref)
+
+	return out.Bytes()
+}
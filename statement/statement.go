@@ -0,0 +1,128 @@
+package statement
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+// Line is an entry of the statement with the balance of the account right after it
+type Line struct {
+	EntryID     int64     `json:"entry_id"`
+	TransferID  int64     `json:"transfer_id,omitempty"`
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Amount      int64     `json:"amount"`
+	Balance     int64     `json:"balance"`
+}
+
+// Statement lists the entries of an account created from From up to, but not including, To
+type Statement struct {
+	AccountID      int64     `json:"account_id"`
+	AccountNumber  string    `json:"account_number"`
+	CurrencyCode   string    `json:"currency_code"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+	TotalDebits    int64     `json:"total_debits"`
+	TotalCredits   int64     `json:"total_credits"`
+	ClosingBalance int64     `json:"closing_balance"`
+	Lines          []Line    `json:"lines"`
+}
+
+// Generate builds the statement of an account for a period, the opening balance is the balance at from
+func Generate(ctx context.Context, store db.Store, account db.Account, from time.Time, to time.Time) (Statement, error) {
+	openingBalance, err := store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+		AccountID: account.ID,
+		At:        from,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to get opening balance %w", err)
+	}
+
+	entries, err := store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromDate:  from,
+		ToDate:    to,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to list statement entries %w", err)
+	}
+
+	return New(account, from, to, openingBalance, entries), nil
+}
+
+// New builds a statement from the opening balance and the entries of the period in the order they were written
+func New(account db.Account, from time.Time, to time.Time, openingBalance int64, entries []db.ListStatementEntriesRow) Statement {
+	statement := Statement{
+		AccountID:      account.ID,
+		AccountNumber:  account.AccountNumber,
+		CurrencyCode:   account.CurrencyCode,
+		From:           from,
+		To:             to,
+		OpeningBalance: openingBalance,
+		ClosingBalance: openingBalance,
+		Lines:          make([]Line, 0, len(entries)),
+	}
+
+	// a transfer debits its from account twice when it charges a fee, the amount first and then the fee
+	debited := make(map[int64]bool)
+
+	for _, entry := range entries {
+		statement.ClosingBalance += entry.Amount
+		if entry.Amount < 0 {
+			statement.TotalDebits -= entry.Amount
+		} else {
+			statement.TotalCredits += entry.Amount
+		}
+
+		fee := false
+		if entry.TransferID.Valid && entry.Amount < 0 {
+			fee = debited[entry.TransferID.Int64]
+			debited[entry.TransferID.Int64] = true
+		}
+
+		statement.Lines = append(statement.Lines, Line{
+			EntryID:     entry.ID,
+			TransferID:  entry.TransferID.Int64,
+			Date:        entry.CreatedAt,
+			Description: describe(entry, fee),
+			Amount:      entry.Amount,
+			Balance:     statement.ClosingBalance,
+		})
+	}
+
+	return statement
+}
+
+// Filename names the statement file of the given extension, e.g. statement-0000001234-20240101-20240201.pdf
+func (statement Statement) Filename(extension string) string {
+	return fmt.Sprintf("statement-%s-%s-%s.%s",
+		statement.AccountNumber, statement.From.Format("20060102"), statement.To.Format("20060102"), extension)
+}
+
+func describe(entry db.ListStatementEntriesRow, fee bool) string {
+	switch {
+	case !entry.TransferID.Valid:
+		return "Entry"
+	case fee:
+		return "Transfer fee"
+	case entry.CounterpartyKind.String == db.AccountKindFunding:
+		return "Deposit"
+	case entry.CounterpartyKind.String == db.AccountKindWithdrawal:
+		return "Withdrawal"
+	case entry.Amount < 0:
+		return fmt.Sprintf("Transfer to account %s", entry.CounterpartyAccountNumber.String)
+	default:
+		return fmt.Sprintf("Transfer from account %s", entry.CounterpartyAccountNumber.String)
+	}
+}
+
+// PreviousMonth returns the UTC calendar month before the one now falls in, as a from and an exclusive to
+func PreviousMonth(now time.Time) (time.Time, time.Time) {
+	year, month, _ := now.UTC().Date()
+	to := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return to.AddDate(0, -1, 0), to
+}
@@ -0,0 +1,181 @@
+package statement
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var (
+	testFrom = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testTo   = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func testStatement() Statement {
+	account := db.Account{ID: 1, AccountNumber: "0000001234", CurrencyCode: util.USD}
+
+	entry := func(id int64, amount int64, transferID int64, accountNumber string, kind string) db.ListStatementEntriesRow {
+		return db.ListStatementEntriesRow{
+			ID:                        id,
+			Amount:                    amount,
+			CreatedAt:                 testFrom.Add(time.Duration(id) * time.Hour),
+			TransferID:                pgtype.Int8{Int64: transferID, Valid: true},
+			CounterpartyAccountNumber: pgtype.Text{String: accountNumber, Valid: true},
+			CounterpartyKind:          pgtype.Text{String: kind, Valid: true},
+		}
+	}
+
+	return New(account, testFrom, testTo, 100, []db.ListStatementEntriesRow{
+		entry(1, 500, 10, "0000000000", db.AccountKindFunding),
+		entry(2, -200, 11, "0000005678", db.AccountKindCustomer),
+		entry(3, -2, 11, "0000005678", db.AccountKindCustomer),
+		entry(4, 50, 12, "0000005678", db.AccountKindCustomer),
+		entry(5, -100, 13, "0000000000", db.AccountKindWithdrawal),
+	})
+}
+
+func TestNew(t *testing.T) {
+	statement := testStatement()
+
+	assert.Equal(t, int64(100), statement.OpeningBalance)
+	assert.Equal(t, int64(302), statement.TotalDebits)
+	assert.Equal(t, int64(550), statement.TotalCredits)
+	assert.Equal(t, int64(348), statement.ClosingBalance)
+
+	descriptions := make([]string, len(statement.Lines))
+	balances := make([]int64, len(statement.Lines))
+	for i, line := range statement.Lines {
+		descriptions[i] = line.Description
+		balances[i] = line.Balance
+	}
+
+	assert.Equal(t, []string{
+		"Deposit",
+		"Transfer to account 0000005678",
+		"Transfer fee",
+		"Transfer from account 0000005678",
+		"Withdrawal",
+	}, descriptions)
+	assert.Equal(t, []int64{600, 400, 398, 448, 348}, balances)
+	assert.Equal(t, "statement-0000001234-20240101-20240201.csv", statement.Filename("csv"))
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := testStatement().WriteCSV(&buf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `date,entry_id,transfer_id,description,amount,balance
+2024-01-01T00:00:00Z,,,Opening balance,,1.00
+2024-01-01T01:00:00Z,1,10,Deposit,5.00,6.00
+2024-01-01T02:00:00Z,2,11,Transfer to account 0000005678,-2.00,4.00
+2024-01-01T03:00:00Z,3,11,Transfer fee,-0.02,3.98
+2024-01-01T04:00:00Z,4,12,Transfer from account 0000005678,0.50,4.48
+2024-01-01T05:00:00Z,5,13,Withdrawal,-1.00,3.48
+2024-02-01T00:00:00Z,,,Closing balance,,3.48
+`, buf.String())
+}
+
+func TestWritePDF(t *testing.T) {
+	statement := testStatement()
+	for i := 0; i < pdfLinesPerPage; i++ {
+		statement.Lines = append(statement.Lines, statement.Lines[0])
+	}
+
+	var buf bytes.Buffer
+	err := statement.WritePDF(&buf)
+	assert.NoError(t, err)
+
+	pdf := buf.String()
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	assert.Contains(t, pdf, "/Count 2")
+	assert.Contains(t, pdf, "(Closing balance: 3.48) Tj")
+
+	// every xref entry must point at the start of its object
+	xref := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf, -1)
+	assert.Len(t, xref, 7)
+	for i, match := range xref {
+		offset, err := strconv.Atoi(match[1])
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(pdf[offset:], fmt.Sprintf("%d 0 obj\n", i+1)))
+	}
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	offset, err := strconv.Atoi(startxref[1])
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(pdf[offset:], "xref\n"))
+}
+
+func TestPdfEscape(t *testing.T) {
+	assert.Equal(t, `a \(b\) \\ ?`, pdfEscape("a (b) \\ é"))
+}
+
+func TestPreviousMonth(t *testing.T) {
+	from, to := PreviousMonth(time.Date(2024, 3, 1, 5, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), to)
+
+	from, to = PreviousMonth(time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, testFrom, to)
+}
+
+func TestWriteCamt053(t *testing.T) {
+	var buf bytes.Buffer
+	err := testStatement().WriteCamt053(&buf, testTo.Add(6*time.Hour))
+	assert.NoError(t, err)
+
+	assertGolden(t, "statement.camt053.xml", buf.Bytes())
+}
+
+func TestWriteMT940(t *testing.T) {
+	var buf bytes.Buffer
+	err := testStatement().WriteMT940(&buf)
+	assert.NoError(t, err)
+
+	assertGolden(t, "statement.mt940", buf.Bytes())
+}
+
+func TestWriteMT940Overdrawn(t *testing.T) {
+	statement := New(db.Account{ID: 1, AccountNumber: "0000001234", CurrencyCode: util.NGN}, testFrom, testTo, -1005, nil)
+
+	var buf bytes.Buffer
+	err := statement.WriteMT940(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), ":60F:D240101NGN10,05\r\n")
+	assert.Contains(t, buf.String(), ":62F:D240131NGN10,05\r\n")
+}
+
+func TestMT940Narrative(t *testing.T) {
+	assert.Equal(t, "Transfer  to account 1", mt940Narrative("Transfer_àto account 1"))
+	assert.Len(t, mt940Narrative(strings.Repeat("a", 100)), mt940NarrativeWidth)
+}
+
+// assertGolden compares output with testdata/<name>.golden, go test ./statement -update rewrites the file
+func assertGolden(t *testing.T, name string, output []byte) {
+	golden := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		err := os.WriteFile(golden, output, 0o644)
+		assert.NoError(t, err)
+	}
+
+	expected, err := os.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(output))
+}
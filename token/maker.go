@@ -1,8 +1,12 @@
 package token
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Maker interface {
-	CreateToken(userID int64, accountName string, email string, duration time.Duration) (string, *Payload, error)
+	CreateToken(userID int64, accountName string, email string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
 	VerifyToken(token string) (*Payload, error)
 }
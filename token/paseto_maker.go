@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aead/chacha20poly1305"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 )
 
@@ -27,8 +28,8 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	return maker, nil
 }
 
-func (pm *PasetoMaker) CreateToken(userID int64, accountName string, email string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(userID, accountName, email, duration)
+func (pm *PasetoMaker) CreateToken(userID int64, accountName string, email string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(userID, accountName, email, sessionID, duration)
 	if err != nil {
 		return "", payload, err
 	}
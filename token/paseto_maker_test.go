@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/kelvinator07/golang-bank-microservices/util"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,12 +16,13 @@ func TestPasetoMaker(t *testing.T) {
 	userID := util.RandomInt(1, 10)
 	accountName := util.RandomAccountName()
 	email := util.RandomEmail()
+	sessionID := uuid.New()
 
 	duration := time.Minute
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, payload, err := maker.CreateToken(userID, accountName, email, duration)
+	token, payload, err := maker.CreateToken(userID, accountName, email, sessionID, duration)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 	assert.NotEmpty(t, payload)
@@ -30,6 +32,7 @@ func TestPasetoMaker(t *testing.T) {
 	assert.NotEmpty(t, payload)
 
 	assert.NotZero(t, payload.ID)
+	assert.Equal(t, sessionID, payload.SessionID)
 	assert.Equal(t, accountName, payload.AccountName)
 	assert.Equal(t, email, payload.Email)
 	assert.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
@@ -43,10 +46,11 @@ func TestExpiredPasetoMakerToken(t *testing.T) {
 	userID := util.RandomInt(1, 10)
 	accountName := util.RandomAccountName()
 	email := util.RandomEmail()
+	sessionID := uuid.New()
 
 	expiredDuration := -time.Minute
 
-	token, payload, err := maker.CreateToken(userID, accountName, email, expiredDuration)
+	token, payload, err := maker.CreateToken(userID, accountName, email, sessionID, expiredDuration)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 	assert.NotEmpty(t, payload)
@@ -65,10 +69,11 @@ func TestInvalidPasetoMakerToken(t *testing.T) {
 	userID := util.RandomInt(1, 10)
 	accountName := util.RandomAccountName()
 	email := util.RandomEmail()
+	sessionID := uuid.New()
 
 	duration := time.Minute
 
-	token, payload, err := maker.CreateToken(userID, accountName, email, duration)
+	token, payload, err := maker.CreateToken(userID, accountName, email, sessionID, duration)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 	assert.NotEmpty(t, payload)
@@ -14,7 +14,10 @@ var (
 
 // Payload data of the token
 type Payload struct {
-	ID          uuid.UUID `json:"id"`
+	ID uuid.UUID `json:"id"`
+	// SessionID binds an access token to the session of the refresh token it was issued with,
+	// it is empty for refresh tokens, whose own ID is the session's
+	SessionID   uuid.UUID `json:"session_id"`
 	UserID      int64     `json:"user_id"`
 	AccountName string    `json:"account_name"`
 	Email       string    `json:"email"`
@@ -22,7 +25,7 @@ type Payload struct {
 	ExpiredAt   time.Time `json:"expired_at"`
 }
 
-func NewPayload(userID int64, accountName string, email string, duration time.Duration) (*Payload, error) {
+func NewPayload(userID int64, accountName string, email string, sessionID uuid.UUID, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom() // len 32
 	if err != nil {
 		return nil, err
@@ -30,6 +33,7 @@ func NewPayload(userID int64, accountName string, email string, duration time.Du
 
 	payload := &Payload{
 		ID:          tokenID,
+		SessionID:   sessionID,
 		UserID:      userID,
 		AccountName: accountName,
 		Email:       email,
@@ -1,15 +1,95 @@
 package util
 
+import (
+	"sort"
+	"sync"
+)
+
 const (
 	USD = "USD"
 	NGN = "NGN"
 	EUR = "EUR"
 )
 
-func IsSupportedCurrency(currencyCode string) bool {
-	switch currencyCode {
-	case USD, EUR, NGN:
-		return true
+// DefaultMinorUnits is used to render amounts in a currency that isn't registered
+const DefaultMinorUnits = 2
+
+// Currency is an ISO 4217 currency, amounts in it are stored as whole numbers of its minor unit
+type Currency struct {
+	Code       string `json:"code"`
+	MinorUnits int    `json:"minor_units"`
+	Symbol     string `json:"symbol"`
+	// accounts can only be opened and money only moved in enabled currencies
+	Enabled bool `json:"enabled"`
+}
+
+// registry holds every currency amounts can be in. It starts with the currencies the bank launched with,
+// the server replaces them with the currencies table on startup
+var registry = struct {
+	sync.RWMutex
+	currencies map[string]Currency
+}{
+	currencies: map[string]Currency{
+		USD: {Code: USD, MinorUnits: 2, Symbol: "$", Enabled: true},
+		NGN: {Code: NGN, MinorUnits: 2, Symbol: "₦", Enabled: true},
+		EUR: {Code: EUR, MinorUnits: 2, Symbol: "€", Enabled: true},
+	},
+}
+
+// SetCurrencies replaces every registered currency
+func SetCurrencies(currencies []Currency) {
+	byCode := make(map[string]Currency, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.currencies = byCode
+}
+
+// RegisterCurrency adds a currency or replaces the registered one with the same code
+func RegisterCurrency(currency Currency) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.currencies[currency.Code] = currency
+}
+
+// LookupCurrency returns the registered currency with the code, whether it's enabled or not
+func LookupCurrency(code string) (Currency, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	currency, ok := registry.currencies[code]
+	return currency, ok
+}
+
+// IsEnabledCurrency reports whether new accounts and money movements can use the currency
+func IsEnabledCurrency(code string) bool {
+	currency, ok := LookupCurrency(code)
+	return ok && currency.Enabled
+}
+
+// CurrencyFor returns the registered currency with the code, or one with DefaultMinorUnits for a code
+// that isn't registered, so amounts that are already stored can always be rendered
+func CurrencyFor(code string) Currency {
+	if currency, ok := LookupCurrency(code); ok {
+		return currency
 	}
-	return false
+	return Currency{Code: code, MinorUnits: DefaultMinorUnits, Symbol: code + " "}
+}
+
+// Currencies lists the registered currencies ordered by code
+func Currencies() []Currency {
+	registry.RLock()
+	list := make([]Currency, 0, len(registry.currencies))
+	for _, currency := range registry.currencies {
+		list = append(list, currency)
+	}
+	registry.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Code < list[j].Code
+	})
+	return list
 }
@@ -8,19 +8,27 @@ import (
 )
 
 type Env struct {
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	MigrationURL         string        `mapstructure:"MIGRATION_URL"`
-	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
-	RedisAddress         string        `mapstructure:"REDIS_ADDRESS"`
-	PostgresUser         string        `mapstructure:"POSTGRES_USER"`
-	PostgresPassword     string        `mapstructure:"POSTGRES_PASSWORD"`
-	PostgresDatabase     string        `mapstructure:"POSTGRES_DATABASE"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	EmailSenderName      string        `mapstructure:"EMAIL_SENDER_NAME"`
-	EmailSenderAddress   string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
-	EmailSenderPassword  string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	DBSource                 string        `mapstructure:"DB_SOURCE"`
+	MigrationURL             string        `mapstructure:"MIGRATION_URL"`
+	ServerAddress            string        `mapstructure:"SERVER_ADDRESS"`
+	RedisAddress             string        `mapstructure:"REDIS_ADDRESS"`
+	PostgresUser             string        `mapstructure:"POSTGRES_USER"`
+	PostgresPassword         string        `mapstructure:"POSTGRES_PASSWORD"`
+	PostgresDatabase         string        `mapstructure:"POSTGRES_DATABASE"`
+	TokenSymmetricKey        string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration      time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration     time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	EmailSenderName          string        `mapstructure:"EMAIL_SENDER_NAME"`
+	EmailSenderAddress       string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	EmailSenderPassword      string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	IdempotencyKeyTTL        time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+	FXRatesFile              string        `mapstructure:"FX_RATES_FILE"`
+	ReconciliationSchedule   string        `mapstructure:"RECONCILIATION_SCHEDULE"`
+	BalanceSnapshotSchedule  string        `mapstructure:"BALANCE_SNAPSHOT_SCHEDULE"`
+	MonthlyStatementSchedule string        `mapstructure:"MONTHLY_STATEMENT_SCHEDULE"`
+	CurrencyRefreshInterval  time.Duration `mapstructure:"CURRENCY_REFRESH_INTERVAL"`
+	BankCode                 string        `mapstructure:"BANK_CODE"`
+	SessionCacheTTL          time.Duration `mapstructure:"SESSION_CACHE_TTL"`
 }
 
 // use viper package to read .env file
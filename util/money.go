@@ -0,0 +1,92 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor units of a currency, e.g. 1050 in USD is 10.50 dollars
+type Money struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// NewMoney wraps an amount of minor units, such as a stored balance, in its currency
+func NewMoney(amount int64, currencyCode string) Money {
+	return Money{Amount: amount, Currency: CurrencyFor(currencyCode)}
+}
+
+// ParseMoney converts a decimal amount such as "1500.5" to minor units of a registered currency
+func ParseMoney(amount string, currencyCode string) (Money, error) {
+	currency, ok := LookupCurrency(currencyCode)
+	if !ok {
+		return Money{}, fmt.Errorf("currency %q is not supported", currencyCode)
+	}
+	return currency.Parse(amount)
+}
+
+// Parse converts a decimal amount such as "-1500.5" to minor units of the currency. The conversion is exact,
+// an amount with more decimal places than the currency has is rejected rather than rounded
+func (currency Currency) Parse(amount string) (Money, error) {
+	digits := strings.TrimPrefix(amount, "-")
+	whole, fraction, hasFraction := strings.Cut(digits, ".")
+	if whole == "" || (hasFraction && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("amount %q is not a decimal number", amount)
+	}
+	if len(fraction) > currency.MinorUnits {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places", amount, currency.MinorUnits)
+	}
+
+	minor, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", currency.MinorUnits-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("amount %q is too large", amount)
+	}
+	if digits != amount {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// String renders the amount as a decimal with the currency's decimal places, e.g. "10.50" or "-0.05"
+func (money Money) String() string {
+	return money.Decimal(".")
+}
+
+// Decimal renders the amount like String with the given decimal separator, some file formats use a comma
+func (money Money) Decimal(separator string) string {
+	amount := strconv.FormatInt(money.Amount, 10)
+
+	sign := ""
+	if money.Amount < 0 {
+		sign, amount = "-", amount[1:]
+	}
+
+	units := money.Currency.MinorUnits
+	if units == 0 {
+		return sign + amount
+	}
+	if len(amount) <= units {
+		amount = strings.Repeat("0", units-len(amount)+1) + amount
+	}
+
+	return sign + amount[:len(amount)-units] + separator + amount[len(amount)-units:]
+}
+
+// Display renders the amount with the currency symbol for people to read, e.g. "$10.50" or "-₦1500.00"
+func (money Money) Display() string {
+	if money.Amount < 0 {
+		return "-" + money.Currency.Symbol + Money{Amount: -money.Amount, Currency: money.Currency}.String()
+	}
+	return money.Currency.Symbol + money.String()
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		currency string
+		expected int64
+		err      string
+	}{
+		{amount: "10", currency: USD, expected: 1000},
+		{amount: "10.5", currency: USD, expected: 1050},
+		{amount: "10.50", currency: USD, expected: 1050},
+		{amount: "-0.05", currency: NGN, expected: -5},
+		{amount: "0.29", currency: EUR, expected: 29},
+		{amount: "92233720368547758.07", currency: USD, expected: 9223372036854775807},
+		{amount: "1.001", currency: USD, err: `amount "1.001" has more than 2 decimal places`},
+		{amount: "1e3", currency: USD, err: `amount "1e3" is not a decimal number`},
+		{amount: "1.", currency: USD, err: `amount "1." is not a decimal number`},
+		{amount: ".5", currency: USD, err: `amount ".5" is not a decimal number`},
+		{amount: "", currency: USD, err: `amount "" is not a decimal number`},
+		{amount: "92233720368547758.08", currency: USD, err: `amount "92233720368547758.08" is too large`},
+		{amount: "1", currency: "GBP", err: `currency "GBP" is not supported`},
+	}
+
+	for _, tc := range testCases {
+		money, err := ParseMoney(tc.amount, tc.currency)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, money.Amount)
+		assert.Equal(t, tc.currency, money.Currency.Code)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "10.50", NewMoney(1050, USD).String())
+	assert.Equal(t, "0.05", NewMoney(5, USD).String())
+	assert.Equal(t, "-0.05", NewMoney(-5, USD).String())
+	assert.Equal(t, "0.00", NewMoney(0, USD).String())
+	assert.Equal(t, "12,34", NewMoney(1234, EUR).Decimal(","))
+	assert.Equal(t, "7", Money{Amount: 7, Currency: Currency{Code: "JPY"}}.String())
+
+	assert.Equal(t, "$10.50", NewMoney(1050, USD).Display())
+	assert.Equal(t, "-₦1500.00", NewMoney(-150000, NGN).Display())
+	assert.Equal(t, "GBP 1.00", NewMoney(100, "GBP").Display())
+}
+
+func TestCurrencies(t *testing.T) {
+	currency, ok := LookupCurrency(NGN)
+	assert.True(t, ok)
+	assert.Equal(t, Currency{Code: NGN, MinorUnits: 2, Symbol: "₦", Enabled: true}, currency)
+
+	_, ok = LookupCurrency("GBP")
+	assert.False(t, ok)
+	assert.Equal(t, DefaultMinorUnits, CurrencyFor("GBP").MinorUnits)
+
+	codes := []string{}
+	for _, currency := range Currencies() {
+		codes = append(codes, currency.Code)
+	}
+	assert.Equal(t, []string{EUR, NGN, USD}, codes)
+}
+
+func TestSetCurrencies(t *testing.T) {
+	registered := Currencies()
+	t.Cleanup(func() { SetCurrencies(registered) })
+
+	SetCurrencies([]Currency{
+		{Code: USD, MinorUnits: 2, Symbol: "$", Enabled: true},
+		{Code: "JPY", MinorUnits: 0, Symbol: "¥", Enabled: false},
+	})
+	assert.True(t, IsEnabledCurrency(USD))
+	assert.False(t, IsEnabledCurrency(NGN))
+	assert.False(t, IsEnabledCurrency("JPY"))
+	assert.Len(t, Currencies(), 2)
+
+	// a disabled currency still parses and renders, so existing amounts in it can be used
+	money, err := ParseMoney("1500", "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, "¥1500", money.Display())
+
+	_, err = ParseMoney("1500.5", "JPY")
+	assert.EqualError(t, err, `amount "1500.5" has more than 0 decimal places`)
+
+	RegisterCurrency(Currency{Code: "JPY", MinorUnits: 0, Symbol: "¥", Enabled: true})
+	assert.True(t, IsEnabledCurrency("JPY"))
+	assert.Len(t, Currencies(), 2)
+}
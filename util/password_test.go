@@ -26,3 +26,28 @@ func TestHashPassword(t *testing.T) {
 	assert.NotEmpty(t, hashedPassword2)
 	assert.NotEqual(t, hashedPassword, hashedPassword2)
 }
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 43)
+	assert.NotContains(t, secret, "+")
+	assert.NotContains(t, secret, "/")
+
+	secret2, err := NewSecret()
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret, secret2)
+}
+
+func TestHashSecret(t *testing.T) {
+	secret, err := NewSecret()
+	assert.NoError(t, err)
+
+	hash := HashSecret(secret)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashSecret(secret))
+
+	secret2, err := NewSecret()
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, HashSecret(secret2))
+}
@@ -63,9 +63,8 @@ func RandomPhoneNumber() int64 {
 	return int64(v)
 }
 
-func RandomAccountNumber() int64 {
-	v, _ := strconv.Atoi(stringWithCharset(10, numbers))
-	return int64(v)
+func RandomAccountNumber() string {
+	return stringWithCharset(10, numbers)
 }
 
 func RandomEmail() string {
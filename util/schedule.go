@@ -0,0 +1,40 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ValidateSchedule checks that schedule is a standard cron expression or a descriptor like @daily or @every 1h
+// An empty schedule is valid and means the transfer only runs once
+func ValidateSchedule(schedule string) error {
+	if schedule == "" {
+		return nil
+	}
+
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	return nil
+}
+
+// NextScheduleRun returns the first time after from that schedule fires
+// ok is false for an empty schedule, a one-off transfer has no next run
+func NextScheduleRun(schedule string, from time.Time) (next time.Time, ok bool, err error) {
+	if schedule == "" {
+		return time.Time{}, false, nil
+	}
+
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+
+	next = sched.Next(from)
+	if next.IsZero() {
+		return time.Time{}, false, nil
+	}
+	return next, true, nil
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	assert.NoError(t, ValidateSchedule(""))
+	assert.NoError(t, ValidateSchedule("0 9 * * 1"))
+	assert.NoError(t, ValidateSchedule("@monthly"))
+	assert.NoError(t, ValidateSchedule("@every 1h30m"))
+
+	assert.Error(t, ValidateSchedule("every day"))
+	assert.Error(t, ValidateSchedule("61 * * * *"))
+}
+
+func TestNextScheduleRun(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 15, 0, 0, time.UTC)
+
+	next, ok, err := NextScheduleRun("", from)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.True(t, next.IsZero())
+
+	next, ok, err = NextScheduleRun("0 9 * * *", from)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC), next)
+
+	next, ok, err = NextScheduleRun("@every 1h", from)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, from.Add(time.Hour), next)
+
+	_, _, err = NextScheduleRun("not a schedule", from)
+	assert.Error(t, err)
+}
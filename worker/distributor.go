@@ -12,6 +12,36 @@ type TaskDistributor interface {
 		payload *PayloadSendVerifyEmail,
 		opts ...asynq.Option,
 	) error
+	DistributeTaskExecuteScheduledTransfer(
+		ctx context.Context,
+		payload *PayloadExecuteScheduledTransfer,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskExpireHold(
+		ctx context.Context,
+		payload *PayloadExpireHold,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendOverdraftNotice(
+		ctx context.Context,
+		payload *PayloadSendOverdraftNotice,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendStatement(
+		ctx context.Context,
+		payload *PayloadSendStatement,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskProcessTransferBatch(
+		ctx context.Context,
+		payload *PayloadProcessTransferBatch,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendPasswordResetEmail(
+		ctx context.Context,
+		payload *PayloadSendPasswordResetEmail,
+		opts ...asynq.Option,
+	) error
 }
 
 type RedisTaskDistributor struct {
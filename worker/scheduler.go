@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+const (
+	// DefaultReconciliationSchedule runs the ledger reconciliation every night at 02:00 UTC
+	DefaultReconciliationSchedule = "0 2 * * *"
+	// DefaultBalanceSnapshotSchedule snapshots the balances just after every UTC midnight
+	DefaultBalanceSnapshotSchedule = "5 0 * * *"
+	// DefaultMonthlyStatementSchedule emails last month's statements on the 1st at 06:00 UTC
+	DefaultMonthlyStatementSchedule = "0 6 1 * *"
+)
+
+type TaskScheduler interface {
+	Start() error
+}
+
+type RedisTaskScheduler struct {
+	scheduler *asynq.Scheduler
+}
+
+// NewRedisTaskScheduler registers the periodic tasks on the cron schedules of the config,
+// an empty schedule falls back to its default
+func NewRedisTaskScheduler(redisOpt asynq.RedisClientOpt, config util.Env) (TaskScheduler, error) {
+	scheduler := asynq.NewScheduler(redisOpt, &asynq.SchedulerOpts{Location: time.UTC})
+
+	reconcileLedger, err := NewTaskReconcileLedger(
+		&PayloadReconcileLedger{TriggeredBy: db.ReconciliationTriggeredBySchedule},
+		asynq.Queue(QueueDefault),
+		asynq.MaxRetry(1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = register(scheduler, config.ReconciliationSchedule, DefaultReconciliationSchedule, reconcileLedger)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotBalances := NewTaskSnapshotBalances(asynq.Queue(QueueDefault))
+	err = register(scheduler, config.BalanceSnapshotSchedule, DefaultBalanceSnapshotSchedule, snapshotBalances)
+	if err != nil {
+		return nil, err
+	}
+
+	sendMonthlyStatements := NewTaskSendMonthlyStatements(asynq.Queue(QueueDefault))
+	err = register(scheduler, config.MonthlyStatementSchedule, DefaultMonthlyStatementSchedule, sendMonthlyStatements)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisTaskScheduler{
+		scheduler: scheduler,
+	}, nil
+}
+
+func register(scheduler *asynq.Scheduler, schedule string, defaultSchedule string, task *asynq.Task) error {
+	if schedule == "" {
+		schedule = defaultSchedule
+	}
+
+	if _, err := scheduler.Register(schedule, task); err != nil {
+		return fmt.Errorf("failed to register %v schedule %w", task.Type(), err)
+	}
+
+	return nil
+}
+
+func (taskScheduler *RedisTaskScheduler) Start() error {
+	return taskScheduler.scheduler.Start()
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+const TaskExecuteScheduledTransfer = "task:execute_scheduled_transfer"
+
+type PayloadExecuteScheduledTransfer struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	RunAt               time.Time `json:"run_at"`
+}
+
+// ScheduledTransferTaskOptions queues the next run of a scheduled transfer for its next_run_at
+// The task ID is unique per run, so queueing the same run twice is rejected with asynq.ErrTaskIDConflict
+func ScheduledTransferTaskOptions(scheduledTransfer db.ScheduledTransfer) []asynq.Option {
+	return []asynq.Option{
+		asynq.MaxRetry(10),
+		asynq.ProcessAt(scheduledTransfer.NextRunAt),
+		asynq.Queue(QueueCritical),
+		asynq.TaskID(fmt.Sprintf("scheduled-transfer-%d-%d", scheduledTransfer.ID, scheduledTransfer.NextRunAt.Unix())),
+	}
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskExecuteScheduledTransfer(
+	ctx context.Context,
+	payload *PayloadExecuteScheduledTransfer,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskExecuteScheduledTransfer, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task %w", err)
+	}
+
+	log.Printf("RedisTaskDistributor Type %v and task Payload: %v", task.Type(), string(info.Payload))
+	log.Printf("RedisTaskDistributor Queue %v and info MaxRetry: %v", info.Queue, info.MaxRetry)
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskExecuteScheduledTransfer(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadExecuteScheduledTransfer
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload %w", err)
+	}
+
+	result, err := processor.store.ExecuteScheduledTransferTx(ctx, db.ExecuteScheduledTransferTxParams{
+		ScheduledTransferID: payload.ScheduledTransferID,
+		RunAt:               payload.RunAt,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("scheduled transfer %d doesnt exist: %w", payload.ScheduledTransferID, asynq.SkipRetry)
+		}
+		if errors.Is(err, db.ErrScheduledTransferNotDue) {
+			log.Printf("RedisTaskProcessor skipped task payload: %v, %v", string(task.Payload()), err)
+			return processor.requeueScheduledTransfer(ctx, payload.ScheduledTransferID)
+		}
+		return fmt.Errorf("failed to execute scheduled transfer %w", err)
+	}
+
+	if result.ScheduledTransfer.Status == db.ScheduledTransferStatusActive {
+		err = processor.distributeScheduledTransfer(ctx, result.ScheduledTransfer)
+		if err != nil {
+			return err
+		}
+	}
+
+	if result.Transfer != nil && result.Transfer.EnteredOverdraft() {
+		payload := &PayloadSendOverdraftNotice{AccountID: result.Transfer.FromAccount.ID}
+		if err := processor.distributor.DistributeTaskSendOverdraftNotice(ctx, payload, asynq.Queue(QueueDefault)); err != nil {
+			log.Printf("RedisTaskProcessor failed to queue overdraft notice for account %d: %v", payload.AccountID, err)
+		}
+	}
+
+	if result.Run.Status == db.ScheduledTransferRunStatusFailed {
+		// the run is already recorded, retrying the task would not send the email again
+		if err := processor.sendScheduledTransferFailedEmail(ctx, result.ScheduledTransfer, result.Run); err != nil {
+			log.Printf("RedisTaskProcessor failed to notify owner of scheduled transfer %d: %v", result.ScheduledTransfer.ID, err)
+		}
+	}
+
+	log.Printf("RedisTaskProcessor Type %v and task payload: %v run status: %v", task.Type(), string(task.Payload()), result.Run.Status)
+
+	return nil
+}
+
+// requeueScheduledTransfer makes sure an active scheduled transfer has its next run queued,
+// which repairs the chain if queueing failed after the previous run committed
+func (processor *RedisTaskProcessor) requeueScheduledTransfer(ctx context.Context, scheduledTransferID int64) error {
+	scheduledTransfer, err := processor.store.GetScheduledTransfer(ctx, scheduledTransferID)
+	if err != nil {
+		return fmt.Errorf("failed to get scheduled transfer %w", err)
+	}
+
+	if scheduledTransfer.Status != db.ScheduledTransferStatusActive {
+		return nil
+	}
+
+	return processor.distributeScheduledTransfer(ctx, scheduledTransfer)
+}
+
+func (processor *RedisTaskProcessor) distributeScheduledTransfer(ctx context.Context, scheduledTransfer db.ScheduledTransfer) error {
+	payload := &PayloadExecuteScheduledTransfer{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               scheduledTransfer.NextRunAt,
+	}
+
+	err := processor.distributor.DistributeTaskExecuteScheduledTransfer(ctx, payload, ScheduledTransferTaskOptions(scheduledTransfer)...)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+	return nil
+}
+
+func (processor *RedisTaskProcessor) sendScheduledTransferFailedEmail(ctx context.Context, scheduledTransfer db.ScheduledTransfer, run db.ScheduledTransferRun) error {
+	user, err := processor.store.GetUser(ctx, scheduledTransfer.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user %w", err)
+	}
+
+	subject := "Your scheduled transfer failed"
+	content := fmt.Sprintf(`Hello %s, <br/>
+	Your scheduled transfer of %s from account %d to account %d could not be made. <br/>
+	Reason: %s <br/>
+	`, user.AccountName, util.NewMoney(scheduledTransfer.Amount, scheduledTransfer.CurrencyCode).Display(),
+		scheduledTransfer.FromAccountID, scheduledTransfer.ToAccountID, run.FailureReason)
+	to := []string{user.Email}
+
+	return processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+const TaskExpireHold = "task:expire_hold"
+
+type PayloadExpireHold struct {
+	HoldID int64 `json:"hold_id"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskExpireHold(
+	ctx context.Context,
+	payload *PayloadExpireHold,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskExpireHold, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task %w", err)
+	}
+
+	log.Printf("RedisTaskDistributor Type %v and task Payload: %v", task.Type(), string(info.Payload))
+	log.Printf("RedisTaskDistributor Queue %v and info MaxRetry: %v", info.Queue, info.MaxRetry)
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskExpireHold(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadExpireHold
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload %w", err)
+	}
+
+	// no row means the hold was captured or voided first, there is nothing left to release
+	hold, err := processor.store.ExpireHold(ctx, payload.HoldID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			log.Printf("RedisTaskProcessor hold %v is no longer active", payload.HoldID)
+			return nil
+		}
+		return fmt.Errorf("failed to expire hold %w", err)
+	}
+
+	log.Printf("RedisTaskProcessor Type %v and task payload: %v released %v on account: %v", task.Type(), string(task.Payload()), hold.Amount, hold.AccountID)
+
+	return nil
+}
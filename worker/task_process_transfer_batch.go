@@ -0,0 +1,152 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+const TaskProcessTransferBatch = "task:process_transfer_batch"
+
+type PayloadProcessTransferBatch struct {
+	BatchID int64 `json:"batch_id"`
+}
+
+// TransferBatchTaskOptions queues a batch once, a retry picks up from the first item that is still pending
+func TransferBatchTaskOptions(batch db.TransferBatch) []asynq.Option {
+	return []asynq.Option{
+		asynq.MaxRetry(10),
+		asynq.Queue(QueueCritical),
+		asynq.TaskID(fmt.Sprintf("transfer-batch-%d", batch.ID)),
+	}
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskProcessTransferBatch(
+	ctx context.Context,
+	payload *PayloadProcessTransferBatch,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskProcessTransferBatch, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task %w", err)
+	}
+
+	log.Printf("RedisTaskDistributor Type %v and task Payload: %v", task.Type(), string(info.Payload))
+	log.Printf("RedisTaskDistributor Queue %v and info MaxRetry: %v", info.Queue, info.MaxRetry)
+
+	return nil
+}
+
+// ProcessTaskProcessTransferBatch makes the batch's transfers one at a time, each in its own transaction,
+// then records how the batch finished and emails the owner a summary
+func (processor *RedisTaskProcessor) ProcessTaskProcessTransferBatch(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadProcessTransferBatch
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload %w", err)
+	}
+
+	batch, err := processor.store.GetTransferBatch(ctx, payload.BatchID)
+	if err != nil {
+		// the task is only queued once the batch is committed, so a missing batch won't turn up on a retry
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("transfer batch %d doesnt exist: %w", payload.BatchID, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get transfer batch %w", err)
+	}
+
+	switch batch.Status {
+	case db.TransferBatchStatusPending:
+		batch, err = processor.store.UpdateTransferBatch(ctx, db.UpdateTransferBatchParams{
+			ID:     batch.ID,
+			Status: pgtype.Text{String: db.TransferBatchStatusProcessing, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update transfer batch %w", err)
+		}
+	case db.TransferBatchStatusProcessing:
+	default:
+		log.Printf("RedisTaskProcessor transfer batch %v is already %v", batch.ID, batch.Status)
+		return nil
+	}
+
+	itemIDs, err := processor.store.ListPendingTransferBatchItemIDs(ctx, batch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list transfer batch items %w", err)
+	}
+
+	overdraftNoticeSent := false
+	for _, itemID := range itemIDs {
+		result, err := processor.store.ExecuteTransferBatchItemTx(ctx, itemID)
+		if err != nil {
+			return fmt.Errorf("failed to execute transfer batch item %d %w", itemID, err)
+		}
+
+		// one notice is enough however many of the batch's transfers went into the overdraft
+		if !overdraftNoticeSent && result.Transfer != nil && result.Transfer.EnteredOverdraft() {
+			payload := &PayloadSendOverdraftNotice{AccountID: result.Transfer.FromAccount.ID}
+			if err := processor.distributor.DistributeTaskSendOverdraftNotice(ctx, payload, asynq.Queue(QueueDefault)); err != nil {
+				log.Printf("RedisTaskProcessor failed to queue overdraft notice for account %d: %v", payload.AccountID, err)
+			}
+			overdraftNoticeSent = true
+		}
+	}
+
+	summary, err := processor.store.GetTransferBatchSummary(ctx, batch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get transfer batch summary %w", err)
+	}
+	if summary.PendingItems > 0 {
+		return fmt.Errorf("transfer batch %d still has %d pending items", batch.ID, summary.PendingItems)
+	}
+
+	batch, err = processor.store.UpdateTransferBatch(ctx, db.UpdateTransferBatchParams{
+		ID:          batch.ID,
+		Status:      pgtype.Text{String: db.TransferBatchStatus(summary), Valid: true},
+		CompletedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update transfer batch %w", err)
+	}
+
+	// the batch is already finished, retrying the task would not send the email again
+	if err := processor.sendTransferBatchEmail(ctx, batch, summary); err != nil {
+		log.Printf("RedisTaskProcessor failed to notify owner of transfer batch %d: %v", batch.ID, err)
+	}
+
+	log.Printf("RedisTaskProcessor Type %v and task payload: %v batch status: %v", task.Type(), string(task.Payload()), batch.Status)
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) sendTransferBatchEmail(ctx context.Context, batch db.TransferBatch, summary db.GetTransferBatchSummaryRow) error {
+	user, err := processor.store.GetUser(ctx, batch.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user %w", err)
+	}
+
+	subject := "Your transfer batch has been processed"
+	content := fmt.Sprintf(`Hello %s, <br/>
+	Your transfer batch %d from account %d has been processed. <br/>
+	%d of %d transfers were made, totalling %s. <br/>
+	%d transfers failed, totalling %s. <br/>
+	`, user.AccountName, batch.ID, batch.FromAccountID,
+		summary.CompletedItems, batch.ItemCount, util.NewMoney(summary.CompletedAmount, batch.CurrencyCode).Display(),
+		summary.FailedItems, util.NewMoney(summary.FailedAmount, batch.CurrencyCode).Display())
+	to := []string{user.Email}
+
+	return processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+const TaskReconcileLedger = "task:reconcile_ledger"
+
+type PayloadReconcileLedger struct {
+	TriggeredBy string `json:"triggered_by"`
+}
+
+func NewTaskReconcileLedger(payload *PayloadReconcileLedger, opts ...asynq.Option) (*asynq.Task, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	return asynq.NewTask(TaskReconcileLedger, jsonPayload, opts...), nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskReconcileLedger(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadReconcileLedger
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload %w", err)
+	}
+
+	result, err := processor.store.ReconcileLedgerTx(ctx, db.ReconcileLedgerTxParams{TriggeredBy: payload.TriggeredBy})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile ledger %w", err)
+	}
+
+	log.Printf("RedisTaskProcessor Type %v reconciliation run %v checked %v accounts and %v transfers, found %v discrepancies",
+		task.Type(), result.Run.ID, result.Run.AccountsChecked, result.Run.TransfersChecked, result.Run.Discrepancies)
+
+	return nil
+}
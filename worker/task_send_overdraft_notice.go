@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+const TaskSendOverdraftNotice = "task:send_overdraft_notice"
+
+type PayloadSendOverdraftNotice struct {
+	AccountID int64 `json:"account_id"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendOverdraftNotice(
+	ctx context.Context,
+	payload *PayloadSendOverdraftNotice,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendOverdraftNotice, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task %w", err)
+	}
+
+	log.Printf("RedisTaskDistributor Type %v and task Payload: %v", task.Type(), string(info.Payload))
+	log.Printf("RedisTaskDistributor Queue %v and info MaxRetry: %v", info.Queue, info.MaxRetry)
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendOverdraftNotice(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendOverdraftNotice
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload %w", err)
+	}
+
+	account, err := processor.store.GetAccount(ctx, payload.AccountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("account %d doesnt exist: %w", payload.AccountID, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get account %w", err)
+	}
+
+	// the account may have been topped up before the task ran, system accounts have nobody to tell
+	if account.Balance >= 0 || !account.UserID.Valid {
+		log.Printf("RedisTaskProcessor account %v is no longer overdrawn", account.ID)
+		return nil
+	}
+
+	user, err := processor.store.GetUser(ctx, account.UserID.Int64)
+	if err != nil {
+		return fmt.Errorf("failed to get user %w", err)
+	}
+
+	subject := "Your account is overdrawn"
+	content := fmt.Sprintf(`Hello %s, <br/>
+	Your account %s is overdrawn. The balance is %s and the overdraft limit is %s. <br/>
+	Please add funds to bring the balance back above zero. <br/>
+	`, user.AccountName, account.AccountNumber,
+		util.NewMoney(account.Balance, account.CurrencyCode).Display(), util.NewMoney(account.OverdraftLimit, account.CurrencyCode).Display())
+	to := []string{user.Email}
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send overdraft notice %w", err)
+	}
+
+	log.Printf("RedisTaskProcessor Type %v and task payload: %v for user: %v", task.Type(), string(task.Payload()), user.Email)
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/util"
+)
+
+const TaskSendPasswordResetEmail = "task:send_password_reset_email"
+
+type PayloadSendPasswordResetEmail struct {
+	Email string `json:"email"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendPasswordResetEmail(
+	ctx context.Context,
+	payload *PayloadSendPasswordResetEmail,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendPasswordResetEmail, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task %w", err)
+	}
+
+	log.Printf("RedisTaskDistributor Type %v and task Payload: %v", task.Type(), string(info.Payload))
+	log.Printf("RedisTaskDistributor Queue %v and info MaxRetry: %v", info.Queue, info.MaxRetry)
+
+	return nil
+}
+
+// ProcessTaskSendPasswordResetEmail emails a single use reset link, only the hash of its secret code is stored
+func (processor *RedisTaskProcessor) ProcessTaskSendPasswordResetEmail(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendPasswordResetEmail
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload %w", err)
+	}
+
+	user, err := processor.store.GetUserByEmail(ctx, payload.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user with email %v doesnt exist", payload.Email)
+		}
+		return fmt.Errorf("failed to get user %w", err)
+	}
+
+	secretCode, err := util.NewSecret()
+	if err != nil {
+		return err
+	}
+
+	// the user's older resets that weren't used stop working, only this link does
+	passwordReset, err := processor.store.CreatePasswordResetTx(ctx, db.CreatePasswordResetParams{
+		Email:          user.Email,
+		SecretCodeHash: util.HashSecret(secretCode),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create password reset %w", err)
+	}
+
+	subject := "Reset your Golang Bank password"
+	resetUrl := fmt.Sprintf("http://localhost:8080/reset-password?secret_code=%s", secretCode)
+	content := fmt.Sprintf(`Hello %s, <br/>
+	We received a request to reset your password. <br/>
+	Please <a href="%s">click here</a> to choose a new one, the link can be used once and expires at %s.<br/>
+	If you didn't ask for this you can ignore this email, your password hasn't changed.<br/>
+	`, user.AccountName, resetUrl, passwordReset.ExpiredAt.UTC().Format("15:04 MST"))
+	to := []string{user.Email}
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email %w", err)
+	}
+
+	log.Printf("RedisTaskProcessor Type %v for user: %v", task.Type(), user.Email)
+
+	return nil
+}
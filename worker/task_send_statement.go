@@ -0,0 +1,150 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+	"github.com/kelvinator07/golang-bank-microservices/statement"
+)
+
+const (
+	TaskSendMonthlyStatements = "task:send_monthly_statements"
+	TaskSendStatement         = "task:send_statement"
+)
+
+type PayloadSendStatement struct {
+	AccountID int64     `json:"account_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+func NewTaskSendMonthlyStatements(opts ...asynq.Option) *asynq.Task {
+	return asynq.NewTask(TaskSendMonthlyStatements, nil, opts...)
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendStatement(
+	ctx context.Context,
+	payload *PayloadSendStatement,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendStatement, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task %w", err)
+	}
+
+	log.Printf("RedisTaskDistributor Type %v and task Payload: %v", task.Type(), string(info.Payload))
+	log.Printf("RedisTaskDistributor Queue %v and info MaxRetry: %v", info.Queue, info.MaxRetry)
+
+	return nil
+}
+
+// ProcessTaskSendMonthlyStatements queues a statement email for last month for every open customer account.
+// The task id makes a second run for the same month skip the accounts that were already queued
+func (processor *RedisTaskProcessor) ProcessTaskSendMonthlyStatements(ctx context.Context, task *asynq.Task) error {
+	from, to := statement.PreviousMonth(time.Now())
+
+	recipients, err := processor.store.ListStatementRecipients(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list statement recipients %w", err)
+	}
+
+	for _, recipient := range recipients {
+		payload := &PayloadSendStatement{
+			AccountID: recipient.AccountID,
+			From:      from,
+			To:        to,
+		}
+		opts := []asynq.Option{
+			asynq.MaxRetry(10),
+			asynq.Queue(QueueDefault),
+			asynq.TaskID(fmt.Sprintf("statement-%d-%s", recipient.AccountID, from.Format("2006-01"))),
+		}
+
+		err = processor.distributor.DistributeTaskSendStatement(ctx, payload, opts...)
+		if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			return fmt.Errorf("failed to distribute statement of account %d %w", recipient.AccountID, err)
+		}
+	}
+
+	log.Printf("RedisTaskProcessor Type %v queued %v statements from %v to %v", task.Type(), len(recipients), from, to)
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendStatement(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendStatement
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload %w", err)
+	}
+
+	account, err := processor.store.GetAccount(ctx, payload.AccountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("account %d doesnt exist: %w", payload.AccountID, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get account %w", err)
+	}
+
+	if !account.UserID.Valid {
+		return fmt.Errorf("account %d is a system account: %w", account.ID, asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, account.UserID.Int64)
+	if err != nil {
+		return fmt.Errorf("failed to get user %w", err)
+	}
+
+	stmt, err := statement.Generate(ctx, processor.store, account, payload.From, payload.To)
+	if err != nil {
+		return err
+	}
+
+	// attachments are read from disk and named after the file
+	dir, err := os.MkdirTemp("", "statement")
+	if err != nil {
+		return fmt.Errorf("failed to create statement dir %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file, err := os.Create(filepath.Join(dir, stmt.Filename("pdf")))
+	if err != nil {
+		return fmt.Errorf("failed to create statement file %w", err)
+	}
+
+	err = stmt.WritePDF(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write statement %w", err)
+	}
+
+	subject := fmt.Sprintf("Your statement for %s", payload.From.Format("January 2006"))
+	content := fmt.Sprintf(`Hello %s, <br/>
+	Please find attached the statement of your %s account %s for %s. <br/>
+	`, user.AccountName, account.CurrencyCode, account.AccountNumber, payload.From.Format("January 2006"))
+	to := []string{user.Email}
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, []string{file.Name()})
+	if err != nil {
+		return fmt.Errorf("failed to send statement %w", err)
+	}
+
+	log.Printf("RedisTaskProcessor Type %v and task payload: %v for user: %v", task.Type(), string(task.Payload()), user.Email)
+
+	return nil
+}
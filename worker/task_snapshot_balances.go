@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/kelvinator07/golang-bank-microservices/db/sqlc"
+)
+
+const TaskSnapshotBalances = "task:snapshot_balances"
+
+func NewTaskSnapshotBalances(opts ...asynq.Option) *asynq.Task {
+	return asynq.NewTask(TaskSnapshotBalances, nil, opts...)
+}
+
+// ProcessTaskSnapshotBalances snapshots every account's balance at the start of the current UTC day,
+// accounts that already have that day's snapshot are left alone so the task can safely run again
+func (processor *RedisTaskProcessor) ProcessTaskSnapshotBalances(ctx context.Context, task *asynq.Task) error {
+	snapshotAt := db.BalanceSnapshotTime(time.Now())
+
+	count, err := processor.store.CreateBalanceSnapshots(ctx, snapshotAt)
+	if err != nil {
+		return fmt.Errorf("failed to create balance snapshots %w", err)
+	}
+
+	log.Printf("RedisTaskProcessor Type %v snapshotted %v account balances at %v", task.Type(), count, snapshotAt)
+
+	return nil
+}